@@ -0,0 +1,17 @@
+//go:build !windows
+
+package slogcolor
+
+import "io"
+
+// EnableWindowsANSI enables the processing of ANSI escape sequences (ENABLE_VIRTUAL_TERMINAL_PROCESSING)
+// on the consoles of os.Stdout and os.Stderr, which older versions of cmd.exe and PowerShell do not enable by default.
+// It returns an error if one of them is not a console or the mode cannot be set. It is a no-op on other platforms.
+func EnableWindowsANSI() error {
+	return nil
+}
+
+// enableWindowsConsole is a no-op on platforms other than Windows, where the terminals process ANSI escape sequences.
+func enableWindowsConsole(io.Writer) bool {
+	return true
+}
@@ -0,0 +1,29 @@
+//go:build !windows
+
+package slogcolor
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestEnableWindowsConsoleNoop(t *testing.T) {
+	if err := EnableWindowsANSI(); err != nil {
+		t.Errorf("EnableWindowsANSI() = %v, want nil", err)
+	}
+	for _, w := range []io.Writer{os.Stdout, os.Stderr, &bytes.Buffer{}} {
+		if !enableWindowsConsole(w) {
+			t.Errorf("enableWindowsConsole(%T) = false, want true", w)
+		}
+	}
+
+	var buf bytes.Buffer
+	slog.New(NewHandler(&buf, &Options{Level: slog.LevelInfo, NoTime: true, ForceColor: true})).Info("msg")
+	if !strings.Contains(buf.String(), "\x1b[") {
+		t.Errorf("colors disabled: %q", buf.String())
+	}
+}
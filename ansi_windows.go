@@ -0,0 +1,45 @@
+//go:build windows
+
+package slogcolor
+
+import (
+	"errors"
+	"io"
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// EnableWindowsANSI enables the processing of ANSI escape sequences (ENABLE_VIRTUAL_TERMINAL_PROCESSING)
+// on the consoles of os.Stdout and os.Stderr, which older versions of cmd.exe and PowerShell do not enable by default.
+// It returns an error if one of them is not a console or the mode cannot be set. It is a no-op on other platforms.
+func EnableWindowsANSI() error {
+	return errors.Join(enableVirtualTerminal(os.Stdout), enableVirtualTerminal(os.Stderr))
+}
+
+// enableWindowsConsole enables the processing of ANSI escape sequences if w is a console, see [Options.SkipWindowsInit].
+// It returns false if w is a console on which it cannot be enabled, so that the escape sequences would be printed literally.
+func enableWindowsConsole(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return true
+	}
+	var mode uint32
+	if err := windows.GetConsoleMode(windows.Handle(f.Fd()), &mode); err != nil {
+		return true // not a console, for example a file, a pipe or a Cygwin terminal
+	}
+	return enableVirtualTerminal(f) == nil
+}
+
+// enableVirtualTerminal enables the processing of ANSI escape sequences on the console of f.
+func enableVirtualTerminal(f *os.File) error {
+	h := windows.Handle(f.Fd())
+	var mode uint32
+	if err := windows.GetConsoleMode(h, &mode); err != nil {
+		return err
+	}
+	if mode&windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING != 0 {
+		return nil
+	}
+	return windows.SetConsoleMode(h, mode|windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING)
+}
@@ -0,0 +1,27 @@
+//go:build windows
+
+package slogcolor_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/geomyidia/slogcolor"
+	"golang.org/x/sys/windows"
+)
+
+func TestEnableWindowsANSI(t *testing.T) {
+	var mode uint32
+	if err := windows.GetConsoleMode(windows.Handle(os.Stdout.Fd()), &mode); err != nil {
+		t.Skip("stdout is not a console:", err)
+	}
+	if err := slogcolor.EnableWindowsANSI(); err != nil {
+		t.Skip("console does not support virtual terminal processing:", err)
+	}
+	if err := windows.GetConsoleMode(windows.Handle(os.Stdout.Fd()), &mode); err != nil {
+		t.Fatal(err)
+	}
+	if mode&windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING == 0 {
+		t.Error("virtual terminal processing is not enabled")
+	}
+}
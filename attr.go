@@ -0,0 +1,604 @@
+package slogcolor
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/fatih/color"
+)
+
+// boundAttr is an attribute together with the groups that were open when it was added.
+type boundAttr struct {
+	groups []string
+	attr   slog.Attr
+}
+
+// key returns the group-qualified key of the attribute, for example "http.status".
+func (ba boundAttr) key() string {
+	if len(ba.groups) == 0 {
+		return ba.attr.Key
+	}
+	return strings.Join(ba.groups, ".") + "." + ba.attr.Key
+}
+
+// appendAttr appends a to attrs, resolving [slog.LogValuer] values, flattening group values into their members and
+// applying [Options.ReplaceAttr] to every member with its full group path, like the slog handlers do.
+// Empty attributes and groups are omitted, and the members of a group with an empty key are inlined.
+func (h *Handler) appendAttr(attrs []boundAttr, groups []string, a slog.Attr) []boundAttr {
+	a.Value = a.Value.Resolve() // stops after 100 nested LogValuers, in case of a cycle
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			groups = append(groups[:len(groups):len(groups)], a.Key)
+		}
+		for _, m := range a.Value.Group() {
+			attrs = h.appendAttr(attrs, groups, m)
+		}
+		return attrs
+	}
+
+	if h.opts.ReplaceAttr != nil {
+		if a = h.opts.ReplaceAttr(groups, a); a.Key == "" {
+			return attrs
+		}
+		a.Value = a.Value.Resolve()
+	}
+	if a.Equal(slog.Attr{}) {
+		return attrs
+	}
+	ba := boundAttr{groups: groups, attr: a}
+	if h.redactKeys != nil || h.opts.RedactKeyPatterns != nil {
+		if h.redactedKey(ba.key()) {
+			ba.attr.Value = slog.StringValue(redacted)
+		}
+	}
+	return append(attrs, ba)
+}
+
+// redactedKey reports whether the value of the attribute with the group-qualified key is redacted,
+// because of [Options.RedactKeys] or [Options.RedactKeyPatterns].
+func (h *Handler) redactedKey(key string) bool {
+	if _, ok := h.redactKeys[strings.ToLower(key)]; ok {
+		return true
+	}
+	for _, re := range h.opts.RedactKeyPatterns {
+		if re.MatchString(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// redacted replaces the values of the attributes in [Options.RedactKeys].
+const redacted = "[REDACTED]"
+
+// collectAttrs appends the attributes of WithAttrs, of [Options.ContextExtractor] and of r to the pooled slice
+// attrsp and returns them, deduplicated and sorted if enabled.
+func (h *Handler) collectAttrs(ctx context.Context, attrsp *[]boundAttr, r slog.Record) []boundAttr {
+	attrs := append(*attrsp, h.attrs...)
+	if h.opts.ContextExtractor != nil && ctx != nil {
+		for _, a := range h.opts.ContextExtractor(ctx) {
+			attrs = h.appendAttr(attrs, nil, a)
+		}
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = h.appendAttr(attrs, h.groups, a)
+		return true
+	})
+	*attrsp = attrs // keep the grown slice in the pool
+	if h.opts.DedupeKeys {
+		attrs = dedupeAttrs(attrs)
+	}
+	if h.opts.SortAttrs || h.attrOrder != nil {
+		h.sortAttrs(attrs)
+	}
+	return attrs
+}
+
+// dedupeAttrs removes the attributes whose group-qualified key occurs again later in attrs, in place,
+// keeping the order of the others.
+func dedupeAttrs(attrs []boundAttr) []boundAttr {
+	seen := make(map[string]struct{}, len(attrs))
+	keep := make([]bool, len(attrs))
+	for i := len(attrs) - 1; i >= 0; i-- {
+		k := attrs[i].key()
+		if _, ok := seen[k]; !ok {
+			seen[k] = struct{}{}
+			keep[i] = true
+		}
+	}
+	n := 0
+	for i, ba := range attrs {
+		if keep[i] {
+			attrs[n] = ba
+			n++
+		}
+	}
+	return attrs[:n]
+}
+
+// compareAttrs orders attributes by key, sorting groups by name among the keys of their enclosing group,
+// so that the members of a group stay together.
+func compareAttrs(a, b boundAttr) int {
+	n := min(len(a.groups), len(b.groups))
+	for i := range n {
+		if c := cmp.Compare(a.groups[i], b.groups[i]); c != 0 {
+			return c
+		}
+	}
+	switch {
+	case len(a.groups) > n:
+		if c := cmp.Compare(a.groups[n], b.attr.Key); c != 0 {
+			return c
+		}
+		return 1
+	case len(b.groups) > n:
+		if c := cmp.Compare(a.attr.Key, b.groups[n]); c != 0 {
+			return c
+		}
+		return -1
+	}
+	return cmp.Compare(a.attr.Key, b.attr.Key)
+}
+
+// attrRank returns the index of the first entry of order that is the key of ba or one of its groups,
+// or len(order) if there is none. The entries are group-qualified keys split at the dots.
+func attrRank(order [][]string, ba boundAttr) int {
+	for i, path := range order {
+		if len(path) > len(ba.groups)+1 {
+			continue
+		}
+		matches := true
+		for j, name := range path {
+			elem := ba.attr.Key
+			if j < len(ba.groups) {
+				elem = ba.groups[j]
+			}
+			if name != elem {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			return i
+		}
+	}
+	return len(order)
+}
+
+// sortAttrs sorts attrs by [Options.AttrOrder] and then by key.
+func (h *Handler) sortAttrs(attrs []boundAttr) {
+	slices.SortStableFunc(attrs, func(a, b boundAttr) int {
+		if c := cmp.Compare(attrRank(h.attrOrder, a), attrRank(h.attrOrder, b)); c != 0 {
+			return c
+		}
+		return compareAttrs(a, b)
+	})
+}
+
+// replaceBuiltin applies [Options.ReplaceAttr] to one of the built-in time, level, source or message attributes.
+// It returns the new value and false if the field should be omitted.
+func (h *Handler) replaceBuiltin(a slog.Attr) (slog.Value, bool) {
+	if h.opts.ReplaceAttr == nil {
+		return a.Value, true
+	}
+	a = h.opts.ReplaceAttr(nil, a)
+	return a.Value.Resolve(), a.Key != ""
+}
+
+// writeAttrs writes attrs to bf according to [Options.GroupStyle].
+// If first is true, nothing is written before the first attribute, otherwise [Options.AttrIndent].
+// The attributes wrapped because of [Options.Width] are indented to the column of bf[msgStart], the message.
+func (h *Handler) writeAttrs(bf *bytes.Buffer, attrs []boundAttr, first bool, msgStart int) {
+	gap := !first // separates the attributes from the message
+	wrap := h.opts.Width > 0 && h.opts.MaxLineWidth <= 0 && h.opts.GroupStyle == GroupFlat
+	lineStart, onLine := 0, !first // the start of the current line and whether anything is on it
+	sep := func() {
+		switch {
+		case gap:
+			bf.WriteString(h.opts.AttrIndent)
+			gap = false
+		case !first:
+			bf.WriteString(h.opts.FieldSeparator)
+		}
+		first = false
+	}
+
+	width := 0
+	if h.opts.AlignKeys {
+		for _, ba := range attrs {
+			width = max(width, utf8.RuneCountInString(h.renderedKey(ba)))
+		}
+	}
+
+	var open []string // groups opened so far, for the indent and brace styles
+	indented := false
+	for _, ba := range attrs {
+		attrStart, keyStart := bf.Len(), 0
+		switch h.opts.GroupStyle {
+		case GroupFlat:
+			sep()
+			keyStart = bf.Len()
+		case GroupBraces:
+			n := commonGroups(open, ba.groups)
+			for range open[n:] {
+				bf.WriteString(" }")
+			}
+			for _, g := range ba.groups[n:] {
+				sep()
+				h.writeColor(bf, h.opts.Theme.Group, g)
+				bf.WriteString("={ ")
+				first = true
+			}
+			open = ba.groups
+			sep()
+		case GroupIndent:
+			n := commonGroups(open, ba.groups)
+			for i, g := range ba.groups[n:] {
+				bf.WriteString("\n" + indent(n+i+1))
+				h.writeColor(bf, h.opts.Theme.Group, g, ":")
+				indented = true
+				gap = false
+			}
+			open = ba.groups
+			if indented {
+				bf.WriteString("\n" + indent(len(ba.groups)+1))
+				first, gap = false, false
+			} else {
+				sep()
+			}
+		}
+
+		key := h.renderedKey(ba)
+		if n := utf8.RuneCountInString(key); n < width {
+			key += strings.Repeat(" ", width-n)
+		}
+		h.writeColor(bf, h.keyColor(ba), key, "=")
+		h.writeAttrValue(bf, ba)
+
+		if wrap && onLine && visibleLen(bf.Bytes()[lineStart:]) > h.opts.Width {
+			wrapAttr(bf, attrStart, keyStart, visibleLen(bf.Bytes()[:msgStart]))
+			lineStart = attrStart + 1
+		}
+		onLine = true
+	}
+
+	if h.opts.GroupStyle == GroupBraces {
+		for range open {
+			bf.WriteString(" }")
+		}
+	}
+}
+
+// writeExpandedAttrs writes attrs to bf for [LayoutExpanded], each on its own indented line as "key: value".
+func (h *Handler) writeExpandedAttrs(bf *bytes.Buffer, attrs []boundAttr) {
+	width := 0
+	if h.opts.AlignKeys {
+		for _, ba := range attrs {
+			width = max(width, utf8.RuneCountInString(ba.key()))
+		}
+	}
+	for _, ba := range attrs {
+		bf.WriteString("\n" + indent(1))
+		key := ba.key()
+		h.writeColor(bf, h.keyColor(ba), key, ":")
+		bf.WriteString(strings.Repeat(" ", 1+max(0, width-utf8.RuneCountInString(key))))
+		h.writeAttrValue(bf, ba)
+	}
+}
+
+// writeMultilineAttrs writes attrs to bf for [Options.Multiline], each on its own line as "key = value",
+// beneath the lines of their groups.
+func (h *Handler) writeMultilineAttrs(bf *bytes.Buffer, attrs []boundAttr) {
+	widths := make(map[string]int) // the longest key in every group
+	for _, ba := range attrs {
+		path := strings.Join(ba.groups, ".")
+		widths[path] = max(widths[path], utf8.RuneCountInString(ba.attr.Key))
+	}
+
+	var open []string
+	for _, ba := range attrs {
+		n := commonGroups(open, ba.groups)
+		for i, g := range ba.groups[n:] {
+			bf.WriteString("\n" + strings.Repeat(h.opts.MultilineIndent, n+i+1))
+			h.writeColor(bf, h.opts.Theme.Group, g, ":")
+		}
+		open = ba.groups
+
+		bf.WriteString("\n" + strings.Repeat(h.opts.MultilineIndent, len(ba.groups)+1))
+		h.writeColor(bf, h.keyColor(ba), ba.attr.Key)
+		pad := widths[strings.Join(ba.groups, ".")] - utf8.RuneCountInString(ba.attr.Key)
+		bf.WriteString(strings.Repeat(" ", pad) + " = ")
+		h.writeAttrValue(bf, ba)
+	}
+}
+
+// keyColor returns the color of the key of ba.
+func (h *Handler) keyColor(ba boundAttr) *color.Color {
+	if strings.Contains(ba.attr.Key, "err") {
+		return h.opts.Theme.ErrorKey
+	}
+	return h.opts.KeyColor
+}
+
+// writeAttrValue writes the value of ba to bf, quoted, escaped and truncated as configured.
+func (h *Handler) writeAttrValue(bf *bytes.Buffer, ba boundAttr) {
+	start := bf.Len()
+	h.writeHighlightedValue(bf, ba)
+	if h.opts.QuoteValues {
+		quoteValue(bf, start)
+	}
+	if h.opts.EscapeNewlines {
+		escapeNewlines(bf, start)
+	}
+	if h.opts.MaxAttrValueLen > 0 {
+		h.truncateValue(bf, start)
+	}
+}
+
+// wrapAttr moves the last attribute in bf, which starts at keyStart after the separator at attrStart,
+// to a new line indented by indent spaces, dropping the separator.
+func wrapAttr(bf *bytes.Buffer, attrStart, keyStart, indent int) {
+	end := bf.Len()
+	n := attrStart + 1 + indent + end - keyStart
+	for bf.Len() < n {
+		bf.WriteByte(' ')
+	}
+	b := bf.Bytes()
+	copy(b[attrStart+1+indent:], b[keyStart:end])
+	b[attrStart] = '\n'
+	for i := attrStart + 1; i < attrStart+1+indent; i++ {
+		b[i] = ' '
+	}
+	bf.Truncate(n)
+}
+
+// keyPattern is a pattern of [Options.HighlightKeyPatterns] with its color.
+type keyPattern struct {
+	re    *regexp.Regexp
+	color *color.Color
+}
+
+// highlightColor returns the color of [Options.HighlightKeys] or [Options.HighlightKeyPatterns] for the group-qualified key.
+func (h *Handler) highlightColor(key string) (*color.Color, bool) {
+	if c, ok := h.opts.HighlightKeys[key]; ok {
+		return c, true
+	}
+	for _, p := range h.highlights {
+		if p.re.MatchString(key) {
+			return p.color, true
+		}
+	}
+	return nil, false
+}
+
+// writeHighlightedValue writes the value of ba to bf in the color of [Options.HighlightKeys] or
+// [Options.HighlightKeyPatterns] for its key, if there is one, or in the color of its kind otherwise.
+func (h *Handler) writeHighlightedValue(bf *bytes.Buffer, ba boundAttr) {
+	if (h.opts.HighlightKeys == nil && h.highlights == nil) || h.opts.NoColor {
+		h.writeValue(bf, ba.attr)
+		return
+	}
+	c, ok := h.highlightColor(ba.key())
+	if !ok {
+		h.writeValue(bf, ba.attr)
+		return
+	}
+	start, end := h.colorSequences(c)
+	bf.WriteString(start)
+	valueStart := bf.Len()
+	h.writeValue(bf, ba.attr)
+	stripANSIFrom(bf, valueStart)
+	bf.WriteString(end)
+}
+
+// writeValue writes the value of a to bf, colored according to its kind.
+func (h *Handler) writeValue(bf *bytes.Buffer, a slog.Attr) {
+	v := a.Value
+	c := h.opts.ValueColor
+	switch v.Kind() {
+	case slog.KindString:
+		if h.opts.HighlightJSON {
+			if s, ok := h.highlightJSON(v.String()); ok {
+				bf.WriteString(s)
+				return
+			}
+		}
+		c = cmp.Or(h.opts.StringColor, c)
+	case slog.KindInt64, slog.KindUint64:
+		if h.opts.FormatBytes && strings.HasSuffix(a.Key, "bytes") {
+			if n, ok := byteCount(v); ok {
+				h.writeColor(bf, cmp.Or(h.opts.NumberColor, c), formatBytes(n))
+				return
+			}
+		}
+		// formatted in place, as the number is not needed as a string
+		start, end := h.colorSequences(cmp.Or(h.opts.NumberColor, c))
+		bf.WriteString(start)
+		if v.Kind() == slog.KindInt64 {
+			bf.Write(strconv.AppendInt(bf.AvailableBuffer(), v.Int64(), 10))
+		} else {
+			bf.Write(strconv.AppendUint(bf.AvailableBuffer(), v.Uint64(), 10))
+		}
+		bf.WriteString(end)
+		return
+	case slog.KindFloat64:
+		c = cmp.Or(h.opts.NumberColor, c)
+	case slog.KindBool:
+		h.writeColor(bf, cmp.Or(h.opts.BoolColor, c), strconv.FormatBool(v.Bool()))
+		return
+	case slog.KindTime:
+		if h.opts.RawTimeValues {
+			c = cmp.Or(h.opts.TimeValueColor, c)
+			break
+		}
+		t := v.Time()
+		if h.opts.TimeLocation != nil {
+			t = t.In(h.opts.TimeLocation)
+		}
+		start, end := h.colorSequences(cmp.Or(h.opts.TimeValueColor, c))
+		bf.WriteString(start)
+		bf.Write(h.appendTime(bf.AvailableBuffer(), slog.TimeValue(t)))
+		bf.WriteString(end)
+		return
+	case slog.KindDuration:
+		if h.opts.FormatDuration {
+			h.writeColor(bf, c, formatDuration(v.Duration()))
+			return
+		}
+		if h.opts.RawTimeValues {
+			start, end := h.colorSequences(c)
+			bf.WriteString(start)
+			bf.Write(strconv.AppendInt(bf.AvailableBuffer(), int64(v.Duration()), 10))
+			bf.WriteString(end)
+			return
+		}
+	case slog.KindAny:
+		if v.Any() == nil {
+			c = cmp.Or(h.opts.NullColor, c)
+			break
+		}
+		if d, ok := v.Any().(time.Duration); ok && h.opts.FormatDuration {
+			h.writeColor(bf, c, formatDuration(d))
+			return
+		}
+		if err, ok := v.Any().(error); ok {
+			s := errorMessage(err)
+			if h.opts.ShowErrorType {
+				s += fmt.Sprintf(" (%T)", err)
+			}
+			h.writeColor(bf, h.opts.ErrorColor, s)
+			return
+		}
+	}
+	h.writeColor(bf, c, v.String())
+}
+
+// quoteValue quotes the attribute value in bf[start:] like logfmt if it contains whitespace, '=', '"'
+// or non-printable characters. The colors around the value are kept, values with several colors are not quoted.
+func quoteValue(bf *bytes.Buffer, start int) {
+	b := bf.Bytes()[start:]
+	i := 0 // end of the leading escape sequences
+	for l := escapeLen(b); l > 0; l = escapeLen(b[i:]) {
+		i += l
+	}
+	j := i // end of the text
+	for k := i; k < len(b); {
+		if l := escapeLen(b[k:]); l > 0 {
+			k += l
+			continue
+		}
+		k++
+		j = k
+	}
+	text := b[i:j]
+	if bytes.IndexByte(text, '\x1b') >= 0 || !bytes.ContainsFunc(text, func(r rune) bool {
+		return unicode.IsSpace(r) || r == '=' || r == '"' || !unicode.IsPrint(r)
+	}) {
+		return
+	}
+	quoted := strconv.Quote(string(text))
+	tail := string(b[j:])
+	bf.Truncate(start + i)
+	bf.WriteString(quoted)
+	bf.WriteString(tail)
+}
+
+// newlineEscaper replaces line breaks with escape sequences, see [Options.EscapeNewlines].
+var newlineEscaper = strings.NewReplacer("\n", `\n`, "\r", `\r`)
+
+// escapeNewlines replaces the line breaks in bf[start:] with escape sequences.
+func escapeNewlines(bf *bytes.Buffer, start int) {
+	if b := bf.Bytes()[start:]; bytes.ContainsAny(b, "\r\n") {
+		escaped := newlineEscaper.Replace(string(b))
+		bf.Truncate(start)
+		bf.WriteString(escaped)
+	}
+}
+
+// maxUnwrapDepth limits the error chain printed with [Options.UnwrapErrors], in case of a cycle.
+const maxUnwrapDepth = 10
+
+// maxCauseFrames is the number of frames printed for the innermost error of a chain, see [Options.UnwrapErrors].
+const maxCauseFrames = 3
+
+// causeColor is the color of the arrows before the wrapped errors.
+var causeColor = color.New(color.Faint)
+
+// errorMessage returns the message of err, or <nil> for a nil pointer, whose Error method would likely panic.
+func errorMessage(err error) string {
+	if isNilPointer(err) {
+		return "<nil>"
+	}
+	return err.Error()
+}
+
+// isNilPointer reports whether err is a nil pointer of a type implementing error.
+func isNilPointer(err error) bool {
+	v := reflect.ValueOf(err)
+	return v.Kind() == reflect.Pointer && v.IsNil()
+}
+
+// writeCauses writes the errors wrapped by the error values in attrs to bf, each on its own line beneath
+// the log line, followed by the top frames of the stack trace of the innermost error if it is a [StackTracer]
+// and its stack trace is not printed because of [Options.StackTraceKey].
+func (h *Handler) writeCauses(bf *bytes.Buffer, attrs []boundAttr) {
+	for _, ba := range attrs {
+		if ba.attr.Value.Kind() != slog.KindAny {
+			continue
+		}
+		err, ok := ba.attr.Value.Any().(error)
+		if !ok || isNilPointer(err) {
+			continue
+		}
+		for i := 0; i < maxUnwrapDepth; i++ {
+			next := errors.Unwrap(err)
+			if next == nil {
+				break
+			}
+			err = next
+			bf.WriteString("\n" + indent(1))
+			h.writeColor(bf, causeColor, "→ caused by: ")
+			h.writeColor(bf, h.opts.ErrorColor, errorMessage(err))
+			if isNilPointer(err) {
+				break
+			}
+		}
+		if st, ok := err.(StackTracer); ok && !isNilPointer(err) && h.stackTrace(ba.attr) == nil {
+			h.writeFrames(bf, st.StackTrace(), maxCauseFrames)
+		}
+	}
+}
+
+// renderedKey returns the key of ba as it is printed with the current [Options.GroupStyle].
+func (h *Handler) renderedKey(ba boundAttr) string {
+	if h.opts.GroupStyle == GroupFlat {
+		return ba.key()
+	}
+	return ba.attr.Key
+}
+
+// commonGroups returns the length of the common prefix of a and b.
+func commonGroups(a, b []string) int {
+	n := 0
+	for n < len(a) && n < len(b) && a[n] == b[n] {
+		n++
+	}
+	return n
+}
+
+// indent returns the indentation for the given nesting level.
+func indent(level int) string {
+	return strings.Repeat("  ", level)
+}
@@ -0,0 +1,135 @@
+package slogcolor_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/geomyidia/slogcolor"
+)
+
+// benchOptions are the options of the Handle benchmarks: colored output without the source.
+func benchOptions() *slogcolor.Options {
+	return &slogcolor.Options{Level: slog.LevelInfo, ForceColor: true, SrcFileMode: slogcolor.Nop}
+}
+
+func BenchmarkHandleInfoNoAttrs(b *testing.B) {
+	h := slogcolor.NewHandler(io.Discard, benchOptions())
+	r := slog.NewRecord(time.Now(), slog.LevelInfo, "request served", 0)
+	ctx := context.Background()
+
+	b.ReportAllocs()
+	for b.Loop() {
+		h.Handle(ctx, r)
+	}
+}
+
+func BenchmarkHandleInfoFiveAttrs(b *testing.B) {
+	h := slogcolor.NewHandler(io.Discard, benchOptions())
+	r := slog.NewRecord(time.Now(), slog.LevelInfo, "request served", 0)
+	r.AddAttrs(
+		slog.String("method", "GET"),
+		slog.String("path", "/api/users"),
+		slog.Int("status", 200),
+		slog.Duration("duration", 750*time.Millisecond),
+		slog.Bool("cached", true),
+	)
+	ctx := context.Background()
+
+	b.ReportAllocs()
+	for b.Loop() {
+		h.Handle(ctx, r)
+	}
+}
+
+func BenchmarkHandleErrorWithSource(b *testing.B) {
+	opts := benchOptions()
+	opts.SrcFileMode = slogcolor.MediumFile
+	h := slogcolor.NewHandler(io.Discard, opts)
+	r := slog.NewRecord(time.Now(), slog.LevelError, "DB connection lost", callerPC())
+	r.AddAttrs(slog.Any("err", errors.New("connection reset")), slog.String("db", "users"))
+	ctx := context.Background()
+
+	b.ReportAllocs()
+	for b.Loop() {
+		h.Handle(ctx, r)
+	}
+}
+
+func BenchmarkHandleWithGroupNested(b *testing.B) {
+	h := slogcolor.NewHandler(io.Discard, benchOptions()).
+		WithAttrs([]slog.Attr{slog.String("app", "srv")}).
+		WithGroup("http").
+		WithAttrs([]slog.Attr{slog.String("method", "GET")}).
+		WithGroup("response")
+	r := slog.NewRecord(time.Now(), slog.LevelInfo, "request served", 0)
+	r.AddAttrs(slog.Int("status", 200), slog.Group("body", slog.Int("bytes", 1024), slog.String("type", "json")))
+	ctx := context.Background()
+
+	b.ReportAllocs()
+	for b.Loop() {
+		h.Handle(ctx, r)
+	}
+}
+
+func BenchmarkLog(b *testing.B) {
+	b.StopTimer()
+	l := slog.New(slogcolor.NewHandler(os.Stderr, slogcolor.DefaultOptions))
+
+	for i := 0; i < b.N; i++ {
+		b.StartTimer()
+		l.Info("benchmarking", "i", i)
+		b.StopTimer()
+	}
+}
+
+func BenchmarkHandle(b *testing.B) {
+	h := slogcolor.NewHandler(io.Discard, &slogcolor.Options{Level: slog.LevelInfo, ForceColor: true})
+	r := slog.NewRecord(time.Now(), slog.LevelInfo, "benchmarking", 0)
+	r.AddAttrs(slog.Int("i", 42), slog.String("path", "/api/users"))
+	ctx := context.Background()
+
+	b.ReportAllocs()
+	for b.Loop() {
+		h.Handle(ctx, r)
+	}
+}
+
+func BenchmarkHandleParallel(b *testing.B) {
+	h := slogcolor.NewHandler(io.Discard, &slogcolor.Options{Level: slog.LevelInfo, ForceColor: true})
+	r := slog.NewRecord(time.Now(), slog.LevelInfo, "benchmarking", 0)
+	r.AddAttrs(slog.Int("i", 42), slog.String("path", "/api/users"))
+	ctx := context.Background()
+
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			h.Handle(ctx, r)
+		}
+	})
+}
+
+func BenchmarkDisabled(b *testing.B) {
+	l := slog.New(slogcolor.NewHandler(io.Discard, &slogcolor.Options{Level: slog.LevelInfo, ForceColor: true})).With("app", "srv")
+
+	b.ReportAllocs()
+	for b.Loop() {
+		l.Debug("disabled", "i", 42, "path", "/api/users", "ok", true)
+	}
+}
+
+func BenchmarkHandleGoroutineID(b *testing.B) {
+	h := slogcolor.NewHandler(io.Discard, &slogcolor.Options{Level: slog.LevelInfo, ForceColor: true, ShowGoroutineID: true})
+	r := slog.NewRecord(time.Now(), slog.LevelInfo, "benchmarking", 0)
+	r.AddAttrs(slog.Int("i", 42), slog.String("path", "/api/users"))
+	ctx := context.Background()
+
+	b.ReportAllocs()
+	for b.Loop() {
+		h.Handle(ctx, r)
+	}
+}
@@ -18,3 +18,20 @@ func getBuffer() *bytes.Buffer {
 func freeBuffer(bf *bytes.Buffer) {
 	bufPool.Put(bf)
 }
+
+var attrsPool = sync.Pool{
+	New: func() interface{} {
+		attrs := make([]boundAttr, 0, 16)
+		return &attrs
+	},
+}
+
+func getAttrs() *[]boundAttr {
+	return attrsPool.Get().(*[]boundAttr)
+}
+
+func freeAttrs(attrs *[]boundAttr) {
+	clear(*attrs) // release the values of the attributes
+	*attrs = (*attrs)[:0]
+	attrsPool.Put(attrs)
+}
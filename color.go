@@ -0,0 +1,142 @@
+package slogcolor
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/mattn/go-isatty"
+)
+
+// noColor is the empty color, which is printed without any escape sequences.
+var noColor = color.New()
+
+// colorSupported reports whether colored output should be written to w.
+// Color is disabled if the NO_COLOR environment variable is set (see https://no-color.org).
+// Otherwise, it is enabled if force is true or if w is a terminal.
+func colorSupported(w io.Writer, force bool) bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	return force || isTerminal(w)
+}
+
+// isTerminal reports whether w is a terminal. Writers without a file descriptor,
+// such as a [bytes.Buffer], are not terminals.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(interface{ Fd() uintptr })
+	if !ok {
+		return false
+	}
+	return isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd())
+}
+
+// trueColorSupported reports whether the terminal advertises 24-bit color support
+// through the COLORTERM environment variable.
+func trueColorSupported() bool {
+	switch os.Getenv("COLORTERM") {
+	case "truecolor", "24bit":
+		return true
+	}
+	return false
+}
+
+// RGBColor is a 24-bit color.
+type RGBColor struct {
+	R, G, B uint8
+}
+
+// Fg returns a [color.Color] with c as the foreground color.
+func (c RGBColor) Fg() *color.Color {
+	return color.RGB(int(c.R), int(c.G), int(c.B))
+}
+
+// Bg returns a [color.Color] with c as the background color.
+func (c RGBColor) Bg() *color.Color {
+	return color.BgRGB(int(c.R), int(c.G), int(c.B))
+}
+
+// colorSequences returns the escape sequences which start and reset c. They ignore the global color detection
+// of fatih/color, which only looks at os.Stdout, as whether color is actually written is decided by the handler.
+func colorSequences(c *color.Color) (start, end string) {
+	if c == nil || c.Equals(noColor) {
+		return "", ""
+	}
+	cc := *c
+	cc.EnableColor()
+	start, end, _ = strings.Cut(cc.Sprint("\x00"), "\x00")
+	return start, end
+}
+
+// sprint returns s colored with c.
+func sprint(c *color.Color, s string) string {
+	start, end := colorSequences(c)
+	if start == "" {
+		return s
+	}
+	return start + s + end
+}
+
+// collectColors computes the escape sequences of the colors of the options of h and of the colors
+// used by default, so that they are not computed for every record. Colors changed afterwards are ignored.
+func (h *Handler) collectColors() {
+	h.colors = make(map[*color.Color][2]string)
+	add := func(c *color.Color) {
+		if _, ok := h.colors[c]; c != nil && !ok {
+			start, end := colorSequences(c)
+			h.colors[c] = [2]string{start, end}
+		}
+	}
+	var walk func(v reflect.Value)
+	walk = func(v reflect.Value) {
+		for i := range v.NumField() {
+			if !v.Type().Field(i).IsExported() {
+				continue
+			}
+			switch f := v.Field(i); {
+			case f.Type() == colorType:
+				add(f.Interface().(*color.Color))
+			case f.Kind() == reflect.Map && f.Type().Elem() == colorType:
+				for it := f.MapRange(); it.Next(); {
+					add(it.Value().Interface().(*color.Color))
+				}
+			case f.Type() == reflect.TypeFor[*Theme]() && !f.IsNil():
+				walk(f.Elem())
+			}
+		}
+	}
+	walk(reflect.ValueOf(h.opts))
+	for _, c := range []*color.Color{causeColor, jsonStringColor, jsonNumberColor, jsonLiteralColor} {
+		add(c)
+	}
+}
+
+// colorSequences returns the escape sequences which start and reset c, see [colorSequences].
+func (h *Handler) colorSequences(c *color.Color) (start, end string) {
+	if seqs, ok := h.colors[c]; ok {
+		return seqs[0], seqs[1]
+	}
+	return colorSequences(c)
+}
+
+// sprint returns s colored with c.
+func (h *Handler) sprint(c *color.Color, s string) string {
+	start, end := h.colorSequences(c)
+	if start == "" {
+		return s
+	}
+	return start + s + end
+}
+
+// writeColor writes the concatenation of s to bf, colored with c.
+func (h *Handler) writeColor(bf *bytes.Buffer, c *color.Color, s ...string) {
+	start, end := h.colorSequences(c)
+	bf.WriteString(start)
+	for _, s := range s {
+		bf.WriteString(s)
+	}
+	bf.WriteString(end)
+}
@@ -0,0 +1,205 @@
+package slogcolor
+
+import (
+	"bytes"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ColorProfile is the set of colors supported by the terminal, see [Options.ColorProfile].
+type ColorProfile int
+
+const (
+	// ColorProfileAuto detects the profile from the COLORTERM and TERM environment variables.
+	ColorProfileAuto ColorProfile = iota
+
+	// ColorProfileNoColor disables colors, like NoColor.
+	ColorProfileNoColor
+
+	// ColorProfileANSI16 supports the 16 basic colors. 256 and 24-bit colors are mapped to the closest of them.
+	ColorProfileANSI16
+
+	// ColorProfileANSI256 supports the 256 xterm colors. 24-bit colors are mapped to the closest of them.
+	ColorProfileANSI256
+
+	// ColorProfileTrueColor supports 24-bit colors.
+	ColorProfileTrueColor
+)
+
+// detectColorProfile returns the profile advertised by the COLORTERM and TERM environment variables.
+func detectColorProfile() ColorProfile {
+	if trueColorSupported() {
+		return ColorProfileTrueColor
+	}
+	term := os.Getenv("TERM")
+	switch {
+	case term == "dumb":
+		return ColorProfileNoColor
+	case strings.Contains(term, "256color"):
+		return ColorProfileANSI256
+	}
+	return ColorProfileANSI16
+}
+
+// ansi16 are the RGB values of the 16 basic colors, as in xterm.
+var ansi16 = [16][3]int{
+	{0, 0, 0}, {205, 0, 0}, {0, 205, 0}, {205, 205, 0}, {0, 0, 238}, {205, 0, 205}, {0, 205, 205}, {229, 229, 229},
+	{127, 127, 127}, {255, 0, 0}, {0, 255, 0}, {255, 255, 0}, {92, 92, 255}, {255, 0, 255}, {0, 255, 255}, {255, 255, 255},
+}
+
+// cubeLevels are the intensities of the 6×6×6 color cube of the 256 colors.
+var cubeLevels = [6]int{0, 95, 135, 175, 215, 255}
+
+// distance returns the squared distance of two RGB colors.
+func distance(a, b [3]int) int {
+	dr, dg, db := a[0]-b[0], a[1]-b[1], a[2]-b[2]
+	return dr*dr + dg*dg + db*db
+}
+
+// nearestCubeLevel returns the index of the intensity of the color cube closest to v.
+func nearestCubeLevel(v int) int {
+	best := 0
+	for i, l := range cubeLevels {
+		if abs(l-v) < abs(cubeLevels[best]-v) {
+			best = i
+		}
+	}
+	return best
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// rgbTo256 returns the 256 color closest to c, either from the color cube or the grayscale ramp.
+func rgbTo256(c [3]int) int {
+	r, g, b := nearestCubeLevel(c[0]), nearestCubeLevel(c[1]), nearestCubeLevel(c[2])
+	cube := 16 + 36*r + 6*g + b
+	gray := min(max((c[0]+c[1]+c[2])/3-8, 0)/10, 23)
+	if distance(c, ansi256RGB(232+gray)) < distance(c, ansi256RGB(cube)) {
+		return 232 + gray
+	}
+	return cube
+}
+
+// ansi256RGB returns the RGB value of the 256 color n.
+func ansi256RGB(n int) [3]int {
+	switch {
+	case n < 16:
+		return ansi16[n]
+	case n < 232:
+		n -= 16
+		return [3]int{cubeLevels[n/36], cubeLevels[n/6%6], cubeLevels[n%6]}
+	}
+	v := 8 + 10*(n-232)
+	return [3]int{v, v, v}
+}
+
+// rgbTo16 returns the index of the basic color closest to c.
+func rgbTo16(c [3]int) int {
+	best := 0
+	for i, b := range ansi16 {
+		if distance(c, b) < distance(c, ansi16[best]) {
+			best = i
+		}
+	}
+	return best
+}
+
+// adaptColors removes the escape sequences from bf if color is disabled, or downgrades its colors
+// to the color profile.
+func (h *Handler) adaptColors(bf *bytes.Buffer) {
+	if h.opts.NoColor && bytes.IndexByte(bf.Bytes(), '\x1b') >= 0 {
+		stripANSI(bf)
+	} else if !h.opts.NoColor && h.opts.ColorProfile < ColorProfileTrueColor {
+		downgradeColors(bf, h.opts.ColorProfile)
+	}
+}
+
+// downgradeColors replaces the 24-bit and 256 colors in the SGR sequences in bf with the closest colors
+// of profile, which is ColorProfileANSI16 or ColorProfileANSI256.
+func downgradeColors(bf *bytes.Buffer, profile ColorProfile) {
+	b := bf.Bytes()
+	if !bytes.Contains(b, []byte("8;2;")) && !bytes.Contains(b, []byte("8;5;")) {
+		return
+	}
+	out := make([]byte, 0, len(b))
+	for i := 0; i < len(b); {
+		l := escapeLen(b[i:])
+		if l == 0 {
+			out = append(out, b[i])
+			i++
+			continue
+		}
+		seq := b[i : i+l]
+		if l > 3 && seq[1] == '[' && seq[l-1] == 'm' {
+			out = append(out, "\x1b["...)
+			out = appendDowngraded(out, string(seq[2:l-1]), profile)
+			out = append(out, 'm')
+		} else {
+			out = append(out, seq...)
+		}
+		i += l
+	}
+	bf.Reset()
+	bf.Write(out)
+}
+
+// appendDowngraded appends the SGR parameters params, with the extended colors replaced for profile, to b.
+func appendDowngraded(b []byte, params string, profile ColorProfile) []byte {
+	ps := strings.Split(params, ";")
+	for i := 0; i < len(ps); i++ {
+		if i > 0 {
+			b = append(b, ';')
+		}
+		p := ps[i]
+		if (p != "38" && p != "48") || i+2 >= len(ps) {
+			b = append(b, p...)
+			continue
+		}
+		var rgb [3]int
+		n := -1 // the 256 color, if the color is one
+		switch ps[i+1] {
+		case "2":
+			if i+4 >= len(ps) {
+				b = append(b, p...)
+				continue
+			}
+			for j := range rgb {
+				rgb[j], _ = strconv.Atoi(ps[i+2+j])
+			}
+			i += 4
+		case "5":
+			n, _ = strconv.Atoi(ps[i+2])
+			rgb = ansi256RGB(n)
+			i += 2
+		default:
+			b = append(b, p...)
+			continue
+		}
+
+		if profile == ColorProfileANSI256 {
+			if n < 0 {
+				n = rgbTo256(rgb)
+			}
+			b = append(b, p...)
+			b = append(b, ";5;"...)
+			b = strconv.AppendInt(b, int64(n), 10)
+			continue
+		}
+		code := rgbTo16(rgb)
+		base := 30
+		if code >= 8 {
+			base, code = 90, code-8
+		}
+		if p == "48" {
+			base += 10
+		}
+		b = strconv.AppendInt(b, int64(base+code), 10)
+	}
+	return b
+}
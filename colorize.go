@@ -0,0 +1,110 @@
+package slogcolor
+
+import (
+	"bytes"
+	"cmp"
+	"io"
+	"log/slog"
+
+	"github.com/fatih/color"
+)
+
+// ColorizeLevel returns the level tag for level as the [Handler] would print it, for use in custom output.
+// If opts is nil, uses [DefaultOptions]. The tag is colored regardless of the terminal,
+// unless [Options.NoColor] is set or the NO_COLOR environment variable is not empty.
+// To color many values with the same options, use a [Colorizer] instead.
+func ColorizeLevel(level slog.Level, opts *Options) string {
+	return NewColorizer(opts).Level(level)
+}
+
+// ColorizeAttr returns the attribute a as the [Handler] would print it, for example key=value,
+// for use in custom output. Groups are rendered according to [Options.GroupStyle] and an empty attribute
+// results in an empty string. If opts is nil, uses [DefaultOptions]. The attribute is colored regardless of
+// the terminal, unless [Options.NoColor] is set or the NO_COLOR environment variable is not empty.
+// To color many values with the same options, use a [Colorizer] instead.
+func ColorizeAttr(a slog.Attr, opts *Options) string {
+	return NewColorizer(opts).Attr(a)
+}
+
+// Colorizer colors levels and attributes like [ColorizeLevel] and [ColorizeAttr], but prepares the options
+// only once. It is safe for concurrent use.
+type Colorizer struct {
+	h *Handler
+}
+
+// NewColorizer creates a new [Colorizer] with the specified options. If opts is nil, uses [DefaultOptions].
+// Like for [NewHandler], the options are copied, so changing them afterwards does not affect the colorizer.
+func NewColorizer(opts *Options) *Colorizer {
+	return &Colorizer{h: newColorizer(opts)}
+}
+
+// Level returns the level tag for level, see [ColorizeLevel].
+func (c *Colorizer) Level(level slog.Level) string {
+	var bf bytes.Buffer
+	bf.WriteString(c.h.levelTag(level))
+	return c.h.finish(&bf)
+}
+
+// Attr returns the attribute a, see [ColorizeAttr].
+func (c *Colorizer) Attr(a slog.Attr) string {
+	var bf bytes.Buffer
+	c.h.writeAttrs(&bf, c.h.appendAttr(nil, nil, a), true, 0)
+	return c.h.finish(&bf)
+}
+
+// The text styles of [Bold], [Dim] and [Underline].
+var (
+	boldStyle      = color.New(color.Bold)
+	dimStyle       = color.New(color.Faint)
+	underlineStyle = color.New(color.Underline)
+)
+
+// Bold returns s in bold, for example for CLI banners. Like the other styling functions, it returns s unchanged
+// if colors are disabled globally with [color.NoColor], which is set if the NO_COLOR environment variable
+// is not empty or os.Stdout is not a terminal.
+func Bold(s string) string {
+	return style(boldStyle, s)
+}
+
+// Dim returns s dimmed, see [Bold].
+func Dim(s string) string {
+	return style(dimStyle, s)
+}
+
+// Underline returns s underlined, see [Bold].
+func Underline(s string) string {
+	return style(underlineStyle, s)
+}
+
+// Colorize returns s styled with the SGR parameters ansiCode, for example "31" for red or "1;38;5;208" for bold orange,
+// followed by a reset, see [Bold].
+func Colorize(s, ansiCode string) string {
+	if color.NoColor || s == "" {
+		return s
+	}
+	return "\x1b[" + ansiCode + "m" + s + "\x1b[0m"
+}
+
+// style returns s in the color c, or s unchanged if colors are disabled globally.
+func style(c *color.Color, s string) string {
+	if color.NoColor || s == "" {
+		return s
+	}
+	return sprint(c, s)
+}
+
+// newColorizer returns a handler with opts which colors its output regardless of the terminal.
+// It never writes, so it does not start the goroutines of the buffer and the hook.
+func newColorizer(opts *Options) *Handler {
+	o := *cmp.Or(opts, DefaultOptions)
+	o.ForceColor = true
+	o.BufferSize, o.FlushInterval = 0, 0
+	o.AfterHandle, o.AsyncHook = nil, false
+	return NewHandler(io.Discard, &o)
+}
+
+// finish returns the contents of bf with the colors of the profile, like the output of Handle.
+func (h *Handler) finish(bf *bytes.Buffer) string {
+	h.adaptColors(bf)
+	return bf.String()
+}
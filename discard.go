@@ -0,0 +1,43 @@
+package slogcolor
+
+import (
+	"context"
+	"log/slog"
+)
+
+// discardHandler is the handler returned by [NewDiscardHandler] and [NewNopHandler].
+type discardHandler struct {
+	enabled bool
+}
+
+// NewDiscardHandler returns a handler which is enabled for all levels but writes nothing,
+// for tests and benchmarks which need a logger without any output.
+func NewDiscardHandler() slog.Handler {
+	return discardHandler{enabled: true}
+}
+
+// NewNopHandler returns a handler which is disabled for all levels, so that the records are not even built.
+// It is useful to measure the cost of the Enabled check in benchmarks.
+func NewNopHandler() slog.Handler {
+	return discardHandler{}
+}
+
+// Enabled implements slog.Handler.Enabled .
+func (h discardHandler) Enabled(context.Context, slog.Level) bool {
+	return h.enabled
+}
+
+// Handle implements slog.Handler.Handle .
+func (h discardHandler) Handle(context.Context, slog.Record) error {
+	return nil
+}
+
+// WithAttrs implements slog.Handler.WithAttrs . It returns the handler itself.
+func (h discardHandler) WithAttrs([]slog.Attr) slog.Handler {
+	return h
+}
+
+// WithGroup implements slog.Handler.WithGroup . It returns the handler itself.
+func (h discardHandler) WithGroup(string) slog.Handler {
+	return h
+}
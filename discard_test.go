@@ -0,0 +1,54 @@
+package slogcolor_test
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/geomyidia/slogcolor"
+)
+
+func TestDiscardHandler(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		h       slog.Handler
+		enabled bool
+	}{
+		{"discard", slogcolor.NewDiscardHandler(), true},
+		{"nop", slogcolor.NewNopHandler(), false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			for _, l := range []slog.Level{slog.LevelDebug - 4, slog.LevelDebug, slog.LevelInfo, slog.LevelError + 4} {
+				if got := tt.h.Enabled(ctx, l); got != tt.enabled {
+					t.Errorf("Enabled(%v) = %v, want %v", l, got, tt.enabled)
+				}
+			}
+			if h := tt.h.WithAttrs([]slog.Attr{slog.Int("a", 1)}).WithGroup("g"); h != tt.h {
+				t.Errorf("WithAttrs and WithGroup returned %v, want the handler itself", h)
+			}
+			if err := tt.h.Handle(ctx, slog.NewRecord(time.Now(), slog.LevelInfo, "msg", 0)); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func BenchmarkDiscardHandler(b *testing.B) {
+	for _, bm := range []struct {
+		name string
+		h    slog.Handler
+	}{
+		{"discard", slogcolor.NewDiscardHandler()},
+		{"nop", slogcolor.NewNopHandler()},
+	} {
+		b.Run(bm.name, func(b *testing.B) {
+			l := slog.New(bm.h)
+			b.ReportAllocs()
+			for b.Loop() {
+				l.Info("request", "method", "GET", "status", 200)
+			}
+		})
+	}
+}
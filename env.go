@@ -0,0 +1,121 @@
+package slogcolor
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// sourceFileModes are the names of the modes accepted by [ParseOptions].
+var sourceFileModes = map[string]SourceFileMode{
+	"nop":       Nop,
+	"short":     ShortFile,
+	"package":   PackageFile,
+	"medium":    MediumFile,
+	"long":      LongFile,
+	"func":      FuncName,
+	"shortfunc": FuncShortName,
+	"line":      LineOnly,
+}
+
+// formats are the names of the formats accepted by [ParseOptions].
+var formats = map[string]Format{
+	"color":  FormatColor,
+	"logfmt": FormatLogfmt,
+	"text":   FormatText,
+}
+
+// themes are the names of the themes accepted by [ParseOptions].
+var themes = map[string]*Theme{
+	"default":    ThemeDefault,
+	"dark":       ThemeDark,
+	"light":      ThemeLight,
+	"monochrome": ThemeMonochrome,
+	"truecolor":  ThemeTrueColor,
+	"dracula":    ThemeDracula,
+	"solarized":  ThemeSolarizedDark,
+}
+
+// ParseOptions returns [DefaultOptions] changed by the environment variables
+// PREFIX_LOG_LEVEL, PREFIX_LOG_FORMAT, PREFIX_LOG_COLOR, PREFIX_LOG_THEME, PREFIX_LOG_SOURCE and PREFIX_LOG_TIME_FORMAT,
+// where PREFIX is prefix, or LOG_LEVEL etc. if prefix is empty. Variables which are not set or empty are ignored.
+//
+//   - LEVEL is a level like debug, INFO or warn+2, see [slog.Level.UnmarshalText].
+//   - FORMAT is color, logfmt or text, see [Format].
+//   - COLOR is auto, always (ForceColor), never (NoColor), or a boolean like true or 0.
+//   - THEME is default, dark, light, monochrome, truecolor, dracula or solarized, see [Theme].
+//   - SOURCE is the [SourceFileMode] nop, short, package, medium, long, func, shortfunc or line.
+//   - TIME_FORMAT is a layout for [time.Time.Format] or one of the TimeFormat constants like unix.
+func ParseOptions(prefix string) (*Options, error) {
+	if prefix != "" {
+		prefix += "_"
+	}
+	opts := *DefaultOptions
+	getenv := func(name string) (string, string) {
+		name = prefix + "LOG_" + name
+		return name, strings.TrimSpace(os.Getenv(name))
+	}
+
+	if name, v := getenv("LEVEL"); v != "" {
+		var l slog.Level
+		if err := l.UnmarshalText([]byte(v)); err != nil {
+			return nil, fmt.Errorf("slogcolor: invalid %s %q: want a level like debug, info, warn or error", name, v)
+		}
+		opts.Level = l
+	}
+
+	if name, v := getenv("FORMAT"); v != "" {
+		f, ok := formats[strings.ToLower(v)]
+		if !ok {
+			return nil, fmt.Errorf("slogcolor: invalid %s %q: want color, logfmt or text", name, v)
+		}
+		opts.Format = f
+	}
+
+	if name, v := getenv("COLOR"); v != "" {
+		switch strings.ToLower(v) {
+		case "auto":
+		case "always", "true", "1", "yes", "on":
+			opts.ForceColor = true
+		case "never", "false", "0", "no", "off":
+			opts.NoColor = true
+		default:
+			return nil, fmt.Errorf("slogcolor: invalid %s %q: want auto, always or never", name, v)
+		}
+	}
+
+	if name, v := getenv("THEME"); v != "" {
+		theme, ok := themes[strings.ToLower(v)]
+		if !ok {
+			return nil, fmt.Errorf("slogcolor: invalid %s %q: want default, dark, light, monochrome, truecolor, dracula or solarized", name, v)
+		}
+		opts.Theme = theme
+	}
+
+	if name, v := getenv("SOURCE"); v != "" {
+		mode, ok := sourceFileModes[strings.ToLower(v)]
+		if !ok {
+			return nil, fmt.Errorf("slogcolor: invalid %s %q: want nop, short, package, medium, long, func, shortfunc or line", name, v)
+		}
+		opts.SrcFileMode = mode
+	}
+
+	if _, v := getenv("TIME_FORMAT"); v != "" {
+		opts.TimeFormat = v
+	}
+
+	return &opts, nil
+}
+
+// MustParseOptions is like [ParseOptions] but panics if an environment variable is invalid.
+// It simplifies configuring the handler from the environment:
+//
+//	slog.SetDefault(slog.New(slogcolor.NewHandler(os.Stderr, slogcolor.MustParseOptions("APP"))))
+func MustParseOptions(prefix string) *Options {
+	opts, err := ParseOptions(prefix)
+	if err != nil {
+		panic(err)
+	}
+	return opts
+}
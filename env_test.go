@@ -0,0 +1,66 @@
+package slogcolor_test
+
+import (
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/geomyidia/slogcolor"
+)
+
+func TestParseOptions(t *testing.T) {
+	t.Setenv("APP_LOG_LEVEL", "debug")
+	t.Setenv("APP_LOG_FORMAT", "logfmt")
+	t.Setenv("APP_LOG_COLOR", "never")
+	t.Setenv("APP_LOG_THEME", "Monochrome")
+	t.Setenv("APP_LOG_SOURCE", "short")
+	t.Setenv("APP_LOG_TIME_FORMAT", time.RFC3339)
+
+	opts, err := slogcolor.ParseOptions("APP")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.Level != slog.LevelDebug || opts.Format != slogcolor.FormatLogfmt || !opts.NoColor ||
+		opts.Theme != slogcolor.ThemeMonochrome || opts.SrcFileMode != slogcolor.ShortFile || opts.TimeFormat != time.RFC3339 {
+		t.Errorf("got %+v", opts)
+	}
+	if slogcolor.DefaultOptions.Level != slog.LevelInfo {
+		t.Error("ParseOptions changed DefaultOptions")
+	}
+}
+
+func TestParseOptionsUnset(t *testing.T) {
+	opts, err := slogcolor.ParseOptions("SLOGCOLOR_UNSET")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.Level != slogcolor.DefaultOptions.Level || opts.SrcFileMode != slogcolor.DefaultOptions.SrcFileMode {
+		t.Errorf("got %+v, want DefaultOptions", opts)
+	}
+}
+
+func TestParseOptionsInvalid(t *testing.T) {
+	for _, tt := range []struct{ name, value string }{
+		{"LOG_LEVEL", "loud"},
+		{"LOG_FORMAT", "json"},
+		{"LOG_COLOR", "rainbow"},
+		{"LOG_THEME", "neon"},
+		{"LOG_SOURCE", "medium-ish"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(tt.name, tt.value)
+			_, err := slogcolor.ParseOptions("")
+			if err == nil || !strings.Contains(err.Error(), tt.name) || !strings.Contains(err.Error(), tt.value) {
+				t.Errorf("got error %v, want one naming %s and %q", err, tt.name, tt.value)
+			}
+
+			defer func() {
+				if recover() == nil {
+					t.Error("MustParseOptions did not panic")
+				}
+			}()
+			slogcolor.MustParseOptions("")
+		})
+	}
+}
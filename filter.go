@@ -0,0 +1,160 @@
+package slogcolor
+
+import (
+	"context"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// LevelFilter returns a [Middleware] that passes only the records for which predicate reports true, for example
+// LevelFilter(AttrEquals("component", "auth")). The record given to predicate also has the attributes added
+// with WithAttrs, and its attributes are nested in the groups of WithGroup.
+func LevelFilter(predicate func(slog.Record) bool) Middleware {
+	return func(next slog.Handler) slog.Handler {
+		return &filterHandler{next: next, predicate: predicate}
+	}
+}
+
+// filterHandler is the handler of [LevelFilter].
+type filterHandler struct {
+	next      slog.Handler
+	predicate func(slog.Record) bool
+	attrs     []slog.Attr // the attributes of WithAttrs, nested in their groups
+	groups    []string
+}
+
+// nest returns attrs nested in the groups of h.
+func (h *filterHandler) nest(attrs []slog.Attr) []slog.Attr {
+	for _, g := range slices.Backward(h.groups) {
+		attrs = []slog.Attr{{Key: g, Value: slog.GroupValue(attrs...)}}
+	}
+	return attrs
+}
+
+// Enabled implements slog.Handler.Enabled .
+func (h *filterHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+// Handle implements slog.Handler.Handle .
+func (h *filterHandler) Handle(ctx context.Context, r slog.Record) error {
+	filtered := r
+	if len(h.attrs) > 0 || len(h.groups) > 0 {
+		filtered = slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+		filtered.AddAttrs(h.attrs...)
+		attrs := make([]slog.Attr, 0, r.NumAttrs())
+		r.Attrs(func(a slog.Attr) bool {
+			attrs = append(attrs, a)
+			return true
+		})
+		if len(attrs) > 0 {
+			filtered.AddAttrs(h.nest(attrs)...)
+		}
+	}
+	if !h.predicate(filtered) {
+		return nil
+	}
+	return h.next.Handle(ctx, r)
+}
+
+// WithAttrs implements slog.Handler.WithAttrs .
+func (h *filterHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	h2 := *h
+	h2.next = h.next.WithAttrs(attrs)
+	h2.attrs = append(slices.Clip(h.attrs), h.nest(attrs)...)
+	return &h2
+}
+
+// WithGroup implements slog.Handler.WithGroup .
+func (h *filterHandler) WithGroup(name string) slog.Handler {
+	h2 := *h
+	h2.next = h.next.WithGroup(name)
+	h2.groups = append(slices.Clip(h.groups), name)
+	return &h2
+}
+
+// AttrEquals returns a predicate for [LevelFilter] reporting whether the record has an attribute with the key
+// whose value is equal to value. If value is a string, it is also equal to values with that string form,
+// for example true to "true". The key of an attribute in a group is qualified with it, for example "http.status".
+func AttrEquals(key string, value any) func(slog.Record) bool {
+	want := slog.AnyValue(value)
+	s, isString := value.(string)
+	return func(r slog.Record) bool {
+		v, ok := lookupAttr(r, key)
+		return ok && (v.Equal(want) || isString && v.String() == s)
+	}
+}
+
+// AttrMatches returns a predicate for [LevelFilter] reporting whether the record has an attribute with the key
+// whose value, as a string, matches re. The key is qualified with the groups as for [AttrEquals].
+func AttrMatches(key string, re *regexp.Regexp) func(slog.Record) bool {
+	return func(r slog.Record) bool {
+		v, ok := lookupAttr(r, key)
+		return ok && re.MatchString(v.String())
+	}
+}
+
+// Not returns a predicate for [LevelFilter] reporting whether p reports false.
+func Not(p func(slog.Record) bool) func(slog.Record) bool {
+	return func(r slog.Record) bool {
+		return !p(r)
+	}
+}
+
+// And returns a predicate for [LevelFilter] reporting whether all of ps report true.
+func And(ps ...func(slog.Record) bool) func(slog.Record) bool {
+	return func(r slog.Record) bool {
+		for _, p := range ps {
+			if !p(r) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Or returns a predicate for [LevelFilter] reporting whether any of ps reports true.
+func Or(ps ...func(slog.Record) bool) func(slog.Record) bool {
+	return func(r slog.Record) bool {
+		for _, p := range ps {
+			if p(r) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// lookupAttr returns the resolved value of the last attribute of r with the group-qualified key.
+func lookupAttr(r slog.Record, key string) (v slog.Value, found bool) {
+	r.Attrs(func(a slog.Attr) bool {
+		if x, ok := lookupIn(a, key); ok {
+			v, found = x, true
+		}
+		return true
+	})
+	return v, found
+}
+
+// lookupIn returns the resolved value of a, or of the last of its members, with the group-qualified key.
+func lookupIn(a slog.Attr, key string) (v slog.Value, found bool) {
+	val := a.Value.Resolve()
+	if val.Kind() != slog.KindGroup {
+		return val, a.Key == key
+	}
+	rest := key
+	if a.Key != "" {
+		var ok bool
+		if rest, ok = strings.CutPrefix(key, a.Key+"."); !ok {
+			return v, false
+		}
+	}
+	for _, m := range val.Group() {
+		if x, ok := lookupIn(m, rest); ok {
+			v, found = x, true
+		}
+	}
+	return v, found
+}
@@ -0,0 +1,54 @@
+package slogcolor_test
+
+import (
+	"bytes"
+	"log/slog"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/geomyidia/slogcolor"
+)
+
+func TestLevelFilter(t *testing.T) {
+	var buf bytes.Buffer
+	h := slogcolor.NewHandlerWithMiddleware(&buf, &slogcolor.Options{Level: slog.LevelInfo, NoColor: true, NoTime: true},
+		slogcolor.LevelFilter(slogcolor.And(slogcolor.AttrEquals("env", "prod"), slogcolor.Not(slogcolor.AttrEquals("noisy", "true")))),
+	)
+	l := slog.New(h)
+	l.Info("kept", "env", "prod")
+	l.Info("noisy", "env", "prod", "noisy", true)
+	l.Info("dev", "env", "dev")
+	l.With("env", "prod").WithGroup("req").Info("bound", "noisy", true)
+
+	want := "INFO  kept env=prod\nINFO  bound env=prod req.noisy=true\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestAttrPredicates(t *testing.T) {
+	r := slog.NewRecord(time.Time{}, slog.LevelInfo, "msg", 0)
+	r.AddAttrs(slog.String("component", "auth"), slog.Group("http", slog.Int("status", 200)))
+	for _, tt := range []struct {
+		name string
+		p    func(slog.Record) bool
+		want bool
+	}{
+		{"equals", slogcolor.AttrEquals("component", "auth"), true},
+		{"not equal", slogcolor.AttrEquals("component", "db"), false},
+		{"missing", slogcolor.AttrEquals("user", "bob"), false},
+		{"group", slogcolor.AttrEquals("http.status", 200), true},
+		{"string form", slogcolor.AttrEquals("http.status", "200"), true},
+		{"group key", slogcolor.AttrEquals("status", 200), false},
+		{"matches", slogcolor.AttrMatches("http.status", regexp.MustCompile(`^2\d\d$`)), true},
+		{"no match", slogcolor.AttrMatches("component", regexp.MustCompile(`^db`)), false},
+		{"or", slogcolor.Or(slogcolor.AttrEquals("component", "db"), slogcolor.AttrEquals("http.status", 200)), true},
+		{"empty and", slogcolor.And(), true},
+		{"empty or", slogcolor.Or(), false},
+	} {
+		if got := tt.p(r); got != tt.want {
+			t.Errorf("%s: got %t, want %t", tt.name, got, tt.want)
+		}
+	}
+}
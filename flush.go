@@ -0,0 +1,136 @@
+package slogcolor
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// defaultFlushInterval is used if [Options.BufferSize] is set but [Options.FlushInterval] is not.
+const defaultFlushInterval = time.Second
+
+// flusher flushes the buffered output of a handler periodically, see [Options.BufferSize].
+// It is shared by all clones of the handler.
+type flusher struct {
+	w    *bufio.Writer
+	mu   *sync.Mutex // the mutex of the handler, guarding w
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+// newFlusher wraps out in a buffer of size bytes and starts flushing it every interval.
+func newFlusher(out io.Writer, mu *sync.Mutex, size int, interval time.Duration) *flusher {
+	f := &flusher{
+		w:    bufio.NewWriterSize(out, size),
+		mu:   mu,
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	go f.run(interval)
+	return f
+}
+
+// run flushes the buffer every interval until the flusher is stopped.
+func (f *flusher) run(interval time.Duration) {
+	defer close(f.done)
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			f.flush()
+		case <-f.stop:
+			return
+		}
+	}
+}
+
+// flush writes the buffered records to the output.
+func (f *flusher) flush() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.w.Flush()
+}
+
+// close stops the background goroutine and flushes the buffer.
+func (f *flusher) close() error {
+	f.once.Do(func() {
+		close(f.stop)
+		<-f.done
+	})
+	return f.flush()
+}
+
+// flushRecord flushes the output after a record for [Options.FlushEachRecord]. It is called under the mutex.
+func (h *Handler) flushRecord() error {
+	if h.flusher != nil {
+		if err := h.flusher.w.Flush(); err != nil {
+			return err
+		}
+	}
+	return flushOutput(h.dst)
+}
+
+// flushOutput flushes or syncs w, if it supports it. Syncing a terminal or a pipe is not an error.
+func flushOutput(w io.Writer) error {
+	switch w := w.(type) {
+	case interface{ Flush() error }:
+		return w.Flush()
+	case interface{ Sync() error }:
+		if err := w.Sync(); err != nil && !errors.Is(err, syscall.EINVAL) && !errors.Is(err, errors.ErrUnsupported) {
+			return err
+		}
+	}
+	return nil
+}
+
+// Flush writes the records buffered because of [Options.BufferSize] to the output.
+// It should be called before the program exits. Without buffering it does nothing.
+func (h *Handler) Flush() error {
+	if h.flusher == nil {
+		return nil
+	}
+	return h.flusher.flush()
+}
+
+// Close implements io.Closer. It stops flushing periodically and writes the records buffered
+// because of [Options.BufferSize] to the output, which is not closed. Records logged after Close
+// are still buffered and only written by Flush. With [Options.AsyncHook], it waits for the pending calls
+// of the hook and stops its workers; the hook of records logged afterwards is called directly.
+// Closing the handler closes all its clones returned by WithAttrs, WithGroup and WithPrefix.
+func (h *Handler) Close() error {
+	if h.hooks != nil {
+		h.hooks.close()
+	}
+	if h.flusher == nil {
+		return nil
+	}
+	return h.flusher.close()
+}
+
+// flushHandlers calls Flush of all handlers which have it, for the handlers combining several.
+func flushHandlers(handlers []slog.Handler) error {
+	var errs []error
+	for _, h := range handlers {
+		if f, ok := h.(interface{ Flush() error }); ok {
+			errs = append(errs, f.Flush())
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// closeHandlers calls Close of all handlers which have it, for the handlers combining several.
+func closeHandlers(handlers []slog.Handler) error {
+	var errs []error
+	for _, h := range handlers {
+		if c, ok := h.(io.Closer); ok {
+			errs = append(errs, c.Close())
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,273 @@
+package slogcolor_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/geomyidia/slogcolor"
+)
+
+func TestBufferSizeClose(t *testing.T) {
+	var buf syncBuffer
+	h := slogcolor.NewHandler(&buf, &slogcolor.Options{
+		Level:         slog.LevelInfo,
+		NoColor:       true,
+		NoTime:        true,
+		BufferSize:    4096,
+		FlushInterval: time.Hour,
+	})
+	l := slog.New(h).With("app", "srv")
+
+	var wg sync.WaitGroup
+	for i := range 100 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			l.Info("msg", "i", i)
+		}()
+	}
+	wg.Wait()
+
+	if got := buf.String(); len(got) >= 4096 || strings.Count(got, "\n") == 100 {
+		t.Errorf("records were not buffered: %d bytes written", len(got))
+	}
+	if err := h.Close(); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+	if n := strings.Count(got, "\n"); n != 100 {
+		t.Errorf("got %d records after Close, want 100", n)
+	}
+	for i := range 100 {
+		if !strings.Contains(got, "INFO  msg app=srv i="+strconv.Itoa(i)+"\n") {
+			t.Errorf("record %d missing", i)
+		}
+	}
+	if err := h.Close(); err != nil {
+		t.Errorf("second Close: %v", err)
+	}
+}
+
+func TestBufferFlush(t *testing.T) {
+	var buf syncBuffer
+	h := slogcolor.NewHandler(&buf, &slogcolor.Options{
+		Level:         slog.LevelInfo,
+		NoColor:       true,
+		NoTime:        true,
+		BufferSize:    4096,
+		FlushInterval: time.Hour,
+	})
+	defer h.Close()
+
+	slog.New(h).Info("hello")
+	if got := buf.String(); got != "" {
+		t.Errorf("got %q before Flush, want nothing", got)
+	}
+	if err := h.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := buf.String(), "INFO  hello\n"; got != want {
+		t.Errorf("got %q after Flush, want %q", got, want)
+	}
+}
+
+func TestFlushInterval(t *testing.T) {
+	var buf syncBuffer
+	h := slogcolor.NewHandler(&buf, &slogcolor.Options{
+		Level:         slog.LevelInfo,
+		NoColor:       true,
+		NoTime:        true,
+		BufferSize:    4096,
+		FlushInterval: time.Millisecond,
+	})
+	defer h.Close()
+
+	slog.New(h).Info("hello")
+	for deadline := time.Now().Add(5 * time.Second); buf.String() == ""; {
+		if time.Now().After(deadline) {
+			t.Fatal("buffer was not flushed")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestFlushUnbuffered(t *testing.T) {
+	var buf syncBuffer
+	h := slogcolor.NewHandler(&buf, &slogcolor.Options{Level: slog.LevelInfo, NoColor: true, NoTime: true})
+	slog.New(h).Info("hello")
+	if got, want := buf.String(), "INFO  hello\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if err := h.Flush(); err != nil {
+		t.Error(err)
+	}
+	if err := h.Close(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestBufferWithOptions(t *testing.T) {
+	var buf syncBuffer
+	h := slogcolor.NewHandler(&buf, &slogcolor.Options{
+		Level:         slog.LevelInfo,
+		NoColor:       true,
+		NoTime:        true,
+		BufferSize:    4096,
+		FlushInterval: time.Hour,
+	})
+	h2 := h.WithOptions(func(o *slogcolor.Options) {
+		o.Level = slog.LevelDebug
+		o.BufferSize = 0
+	})
+
+	slog.New(h2).Debug("debug")
+	slog.New(h).Info("info")
+	if got := buf.String(); got != "" {
+		t.Errorf("got %q before Close, want nothing", got)
+	}
+	if err := h.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := buf.String(), "DEBUG debug\nINFO  info\n"; got != want {
+		t.Errorf("got %q after Close, want %q", got, want)
+	}
+}
+
+// eventWriter records the writes and the calls of Flush or Sync of the writers embedding it.
+type eventWriter struct {
+	events []string
+	err    error
+}
+
+func (w *eventWriter) Write(p []byte) (int, error) {
+	w.events = append(w.events, "write "+string(p))
+	return len(p), nil
+}
+
+// flushWriter is a writer with a Flush method like [bufio.Writer].
+type flushWriter struct{ eventWriter }
+
+func (w *flushWriter) Flush() error {
+	w.events = append(w.events, "flush")
+	return w.err
+}
+
+// syncWriter is a writer with a Sync method like [os.File].
+type syncWriter struct{ eventWriter }
+
+func (w *syncWriter) Sync() error {
+	w.events = append(w.events, "sync")
+	return w.err
+}
+
+func TestFlushEachRecord(t *testing.T) {
+	opts := &slogcolor.Options{Level: slog.LevelInfo, NoColor: true, NoTime: true, FlushEachRecord: true}
+
+	var w flushWriter
+	l := slog.New(slogcolor.NewHandler(&w, opts))
+	l.Info("one")
+	l.Info("two")
+	if want := []string{"write INFO  one\n", "flush", "write INFO  two\n", "flush"}; !slices.Equal(w.events, want) {
+		t.Errorf("Flush: got %q, want %q", w.events, want)
+	}
+
+	var s syncWriter
+	slog.New(slogcolor.NewHandler(&s, opts)).Info("one")
+	if want := []string{"write INFO  one\n", "sync"}; !slices.Equal(s.events, want) {
+		t.Errorf("Sync: got %q, want %q", s.events, want)
+	}
+
+	w = flushWriter{eventWriter{err: errors.New("disk full")}}
+	if err := slogcolor.NewHandler(&w, opts).Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelInfo, "msg", 0)); err != w.err {
+		t.Errorf("got error %v, want %v", err, w.err)
+	}
+
+	s = syncWriter{eventWriter{err: &os.PathError{Op: "sync", Path: "/dev/stderr", Err: syscall.EINVAL}}}
+	if err := slogcolor.NewHandler(&s, opts).Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelInfo, "msg", 0)); err != nil {
+		t.Errorf("got error %v syncing a terminal, want nil", err)
+	}
+}
+
+func TestFlushEachRecordBuffered(t *testing.T) {
+	var w flushWriter
+	h := slogcolor.NewHandler(&w, &slogcolor.Options{
+		Level:           slog.LevelInfo,
+		NoColor:         true,
+		NoTime:          true,
+		BufferSize:      4096,
+		FlushInterval:   time.Hour,
+		FlushEachRecord: true,
+	})
+	defer h.Close()
+
+	slog.New(h).Info("one")
+	if want := []string{"write INFO  one\n", "flush"}; !slices.Equal(w.events, want) {
+		t.Errorf("got %q, want %q", w.events, want)
+	}
+}
+
+func TestFlushEachRecordTee(t *testing.T) {
+	var w flushWriter
+	var s syncWriter
+	l := slog.New(slogcolor.NewTeeHandler([]slogcolor.TeeOutput{{Writer: &w}, {Writer: &s}},
+		&slogcolor.Options{Level: slog.LevelInfo, NoTime: true, FlushEachRecord: true}))
+	l.Info("one")
+	if want := []string{"write INFO  one\n", "flush"}; !slices.Equal(w.events, want) {
+		t.Errorf("Flush: got %q, want %q", w.events, want)
+	}
+	if want := []string{"write INFO  one\n", "sync"}; !slices.Equal(s.events, want) {
+		t.Errorf("Sync: got %q, want %q", s.events, want)
+	}
+}
+
+func TestBufferCombinedHandlers(t *testing.T) {
+	opts := &slogcolor.Options{Level: slog.LevelInfo, NoColor: true, NoTime: true, BufferSize: 4096, FlushInterval: time.Hour}
+	for _, tt := range []struct {
+		name string
+		new  func(a, b *syncBuffer) slog.Handler
+	}{
+		{"routed", func(a, b *syncBuffer) slog.Handler {
+			return slogcolor.NewRoutedHandler([]slogcolor.LevelRoute{
+				{MinLevel: slog.LevelInfo, MaxLevel: slog.LevelInfo, Writer: a},
+				{MinLevel: slog.LevelInfo, MaxLevel: slog.LevelError, Writer: b},
+			}, opts)
+		}},
+		{"multi", func(a, b *syncBuffer) slog.Handler { return slogcolor.NewMultiHandler(a, b, opts) }},
+	} {
+		var a, b syncBuffer
+		h := tt.new(&a, &b)
+		slog.New(h).With("app", "srv").Info("one")
+		if a.String() != "" || b.String() != "" {
+			t.Errorf("%s: records were not buffered: %q, %q", tt.name, a.String(), b.String())
+		}
+
+		if err := h.(interface{ Flush() error }).Flush(); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		for _, buf := range []*syncBuffer{&a, &b} {
+			if got, want := buf.String(), "INFO  one app=srv\n"; got != want {
+				t.Errorf("%s: got %q after Flush, want %q", tt.name, got, want)
+			}
+		}
+
+		slog.New(h).Info("two")
+		if err := h.(io.Closer).Close(); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		for _, buf := range []*syncBuffer{&a, &b} {
+			if got, want := buf.String(), "INFO  one app=srv\nINFO  two\n"; got != want {
+				t.Errorf("%s: got %q after Close, want %q", tt.name, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package slogcolor
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"unicode"
+)
+
+// Format is the output format of the handler.
+type Format int
+
+const (
+	// FormatColor is the default colored format (for example 2024-01-02 15:04:05 INFO  main.go:42 | hello k=v).
+	FormatColor Format = iota
+
+	// FormatLogfmt writes every record as logfmt key=value pairs without colors
+	// (for example time="2024-01-02 15:04:05" level=INFO source=main.go:42 msg=hello k=v).
+	// Values containing whitespace, '=', '"' or non-printable characters are quoted, attributes in groups
+	// are qualified with the group names, and options which only affect the layout, like MsgPrefix,
+	// MsgLength, GroupStyle, MaxLineWidth or the prefix of WithPrefix, are ignored.
+	FormatLogfmt
+
+	// FormatText is the layout of FormatColor without colors, quoting only the values which are not simple strings
+	// as with QuoteValues.
+	FormatText
+)
+
+// writeLogfmt writes r to bf as logfmt, see [FormatLogfmt], without the trailing newline.
+func (h *Handler) writeLogfmt(ctx context.Context, bf *bytes.Buffer, r slog.Record) {
+	if h.opts.OmitFields&OmitTime == 0 && !r.Time.IsZero() {
+		t := r.Time
+		if h.opts.TimeLocation != nil {
+			t = t.In(h.opts.TimeLocation)
+		}
+		if v, ok := h.replaceBuiltin(slog.Time(slog.TimeKey, t)); ok {
+			writeLogfmtKey(bf, slog.TimeKey)
+			start := bf.Len()
+			bf.Write(h.appendTime(bf.AvailableBuffer(), v))
+			quoteValue(bf, start)
+		}
+	}
+
+	if h.opts.OmitFields&OmitLevel == 0 {
+		if v, ok := h.replaceBuiltin(slog.Any(slog.LevelKey, r.Level)); ok {
+			writeLogfmtKey(bf, slog.LevelKey)
+			start := bf.Len()
+			if l, isLevel := v.Any().(slog.Level); isLevel {
+				bf.WriteString(h.levelLabel(l))
+			} else {
+				bf.WriteString(v.String())
+			}
+			quoteValue(bf, start)
+		}
+	}
+
+	if h.opts.OmitFields&OmitSource == 0 {
+		if src := h.source(r.PC, r.Level); src != nil {
+			if v, ok := h.replaceBuiltin(slog.Any(slog.SourceKey, src)); ok {
+				// the source is formatted as in the other formats, only the padding is removed
+				start := bf.Len()
+				if src, isSource := v.Any().(*slog.Source); isSource {
+					h.writeSource(bf, src)
+				} else {
+					bf.WriteString(v.String())
+				}
+				formatted := strings.TrimSpace(string(bf.Bytes()[start:]))
+				bf.Truncate(start)
+				if formatted != "" {
+					writeLogfmtKey(bf, slog.SourceKey)
+					h.writeLogfmtValue(bf, slog.String(slog.SourceKey, formatted))
+				}
+			}
+		}
+	}
+
+	if h.opts.OmitFields&OmitMessage == 0 {
+		if v, ok := h.replaceBuiltin(slog.String(slog.MessageKey, r.Message)); ok {
+			writeLogfmtKey(bf, slog.MessageKey)
+			msg := v.String()
+			if h.opts.MaxMessageLength > 0 {
+				msg = h.truncateMessage(msg)
+			}
+			h.writeLogfmtValue(bf, slog.String(slog.MessageKey, msg))
+		}
+	}
+
+	attrsp := getAttrs()
+	defer freeAttrs(attrsp)
+	attrs := h.collectAttrs(ctx, attrsp, r)
+
+	for _, ba := range attrs {
+		writeLogfmtKey(bf, ba.key())
+		h.writeLogfmtValue(bf, ba.attr)
+	}
+}
+
+// writeLogfmtKey writes the separator before key, if it is not the first pair, and key= to bf.
+// Characters which are not allowed in logfmt keys are replaced with '_'.
+func writeLogfmtKey(bf *bytes.Buffer, key string) {
+	if bf.Len() > 0 {
+		bf.WriteString(" ")
+	}
+	if key == "" {
+		key = "_"
+	}
+	for _, r := range key {
+		if r <= ' ' || r == '=' || r == '"' || !unicode.IsPrint(r) {
+			r = '_'
+		}
+		bf.WriteRune(r)
+	}
+	bf.WriteString("=")
+}
+
+// writeLogfmtValue writes the value of a to bf without colors, truncated to [Options.MaxAttrValueLen] and quoted if needed.
+func (h *Handler) writeLogfmtValue(bf *bytes.Buffer, a slog.Attr) {
+	start := bf.Len()
+	h.writeValue(bf, a)
+	stripANSIFrom(bf, start)
+	if h.opts.MaxAttrValueLen > 0 {
+		h.truncateValue(bf, start)
+	}
+	quoteValue(bf, start)
+}
@@ -0,0 +1,128 @@
+package slogcolor_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/geomyidia/slogcolor"
+	"github.com/kr/logfmt"
+)
+
+// parseLogfmt decodes a logfmt line with github.com/kr/logfmt.
+func parseLogfmt(line string) ([][2]string, error) {
+	var pairs [][2]string
+	err := logfmt.Unmarshal([]byte(line), logfmt.HandlerFunc(func(key, val []byte) error {
+		pairs = append(pairs, [2]string{string(key), string(val)})
+		return nil
+	}))
+	return pairs, err
+}
+
+func TestFormatLogfmt(t *testing.T) {
+	var buf bytes.Buffer
+	h := slogcolor.NewHandler(&buf, &slogcolor.Options{
+		Level:       slog.LevelInfo,
+		Format:      slogcolor.FormatLogfmt,
+		ForceColor:  true,
+		SrcFileMode: slogcolor.ShortFile,
+		MsgPrefix:   "| ",
+	})
+	r := slog.NewRecord(time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC), slog.LevelWarn, "hello world", callerPC())
+	r.AddAttrs(
+		slog.String("simple", "value"),
+		slog.String("spaces", "a b"),
+		slog.String("eq", "a=b"),
+		slog.String("quote", `say "hi"`),
+		slog.String("newline", "a\nb"),
+		slog.String("empty", ""),
+		slog.Int("n", 42),
+		slog.Any("err", errors.New("boom")),
+		slog.Group("http", slog.Int("status", 200)),
+	)
+	h.Handle(context.Background(), r)
+
+	line := strings.TrimSuffix(buf.String(), "\n")
+	if strings.Contains(line, "\x1b") {
+		t.Errorf("output contains escape sequences: %q", line)
+	}
+	pairs, err := parseLogfmt(line)
+	if err != nil {
+		t.Fatalf("parse %q: %v", line, err)
+	}
+	want := [][2]string{
+		{"time", "2024-01-02 15:04:05"},
+		{"level", "WARN"},
+		{"source", pairs[2][1]},
+		{"msg", "hello world"},
+		{"simple", "value"},
+		{"spaces", "a b"},
+		{"eq", "a=b"},
+		{"quote", `say "hi"`},
+		{"newline", "a\nb"},
+		{"empty", ""},
+		{"n", "42"},
+		{"err", "boom"},
+		{"http.status", "200"},
+	}
+	if fmt.Sprint(pairs) != fmt.Sprint(want) {
+		t.Errorf("got %q\nwant %q", pairs, want)
+	}
+	if !strings.HasPrefix(pairs[2][1], "format_test.go:") {
+		t.Errorf("source = %q, want the ShortFile name", pairs[2][1])
+	}
+}
+
+func TestFormatLogfmtSourceFileMode(t *testing.T) {
+	for _, tt := range []struct {
+		mode slogcolor.SourceFileMode
+		want string
+	}{
+		{slogcolor.Nop, ""},
+		{slogcolor.ShortFile, "format_test.go:"},
+		{slogcolor.LongFile, "/"},
+	} {
+		var buf bytes.Buffer
+		h := slogcolor.NewHandler(&buf, &slogcolor.Options{
+			Level:       slog.LevelInfo,
+			Format:      slogcolor.FormatLogfmt,
+			NoTime:      true,
+			SrcFileMode: tt.mode,
+		})
+		h.Handle(context.Background(), slog.NewRecord(time.Time{}, slog.LevelInfo, "msg", callerPC()))
+		pairs, err := parseLogfmt(strings.TrimSuffix(buf.String(), "\n"))
+		if err != nil {
+			t.Fatalf("%v: %v", tt.mode, err)
+		}
+		source := ""
+		for _, p := range pairs {
+			if p[0] == slog.SourceKey {
+				source = p[1]
+			}
+		}
+		if tt.want == "" && source != "" || !strings.HasPrefix(source, tt.want) {
+			t.Errorf("%v: source = %q, want prefix %q", tt.mode, source, tt.want)
+		}
+	}
+}
+
+func TestFormatText(t *testing.T) {
+	var buf bytes.Buffer
+	h := slogcolor.NewHandler(&buf, &slogcolor.Options{
+		Level:      slog.LevelInfo,
+		Format:     slogcolor.FormatText,
+		ForceColor: true,
+		NoTime:     true,
+	})
+	slog.New(h).Info("hello world", "simple", "value", "spaces", "a b")
+
+	want := "INFO  hello world simple=value spaces=\"a b\"\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -2,10 +2,11 @@ module github.com/geomyidia/slogcolor
 
 go 1.24.4
 
-require github.com/fatih/color v1.18.0
-
 require (
-	github.com/mattn/go-colorable v0.1.13 // indirect
-	github.com/mattn/go-isatty v0.0.20 // indirect
-	golang.org/x/sys v0.25.0 // indirect
+	github.com/fatih/color v1.18.0
+	github.com/kr/logfmt v0.0.0-20140226030751-b84e30acd515
+	github.com/mattn/go-isatty v0.0.20
+	golang.org/x/sys v0.25.0
 )
+
+require github.com/mattn/go-colorable v0.1.13 // indirect
@@ -0,0 +1,39 @@
+package slogcolor
+
+import (
+	"bytes"
+	"runtime"
+	"strconv"
+)
+
+// goroutineID returns the ID of the current goroutine, parsed from the header of its stack trace
+// (for example "goroutine 42 [running]:"), or 0 if it cannot be parsed.
+// This is slow compared to the rest of a record, see [Options.ShowGoroutineID].
+func goroutineID() uint64 {
+	var buf [64]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+	b, ok := bytes.CutPrefix(b, []byte("goroutine "))
+	if !ok {
+		return 0
+	}
+	if i := bytes.IndexByte(b, ' '); i >= 0 {
+		b = b[:i]
+	}
+	id, err := strconv.ParseUint(string(b), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return id
+}
+
+// appendGoroutineID appends the ID of the current goroutine to b as 6 zero-padded hex digits.
+func appendGoroutineID(b []byte) []byte {
+	id := goroutineID()
+	// pad to 6 digits
+	for i := 20; i > 0; i -= 4 {
+		if id < 1<<i {
+			b = append(b, '0')
+		}
+	}
+	return strconv.AppendUint(b, id, 16)
+}
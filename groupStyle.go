@@ -0,0 +1,15 @@
+package slogcolor
+
+// GroupStyle is the way attributes in groups are rendered.
+type GroupStyle int
+
+const (
+	// GroupFlat qualifies the keys with the group names (for example http.req.status=200).
+	GroupFlat GroupStyle = iota
+
+	// GroupIndent puts each group and its attributes on indented continuation lines, two spaces per nesting level.
+	GroupIndent
+
+	// GroupBraces renders each group inline in braces (for example http={ req={ status=200 } }).
+	GroupBraces
+)
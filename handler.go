@@ -1,17 +1,15 @@
 package slogcolor
 
 import (
+	"bytes"
 	"context"
-	"fmt"
 	"io"
 	"log/slog"
-	"maps"
-	"os"
-	"path/filepath"
-	"runtime"
-	"strconv"
+	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/fatih/color"
 )
@@ -19,41 +17,214 @@ import (
 // Handler is a colored slog handler.
 type Handler struct {
 	groups []string
-	attrs  []slog.Attr
+	attrs  []boundAttr
+	prefix string // prefixes of WithPrefix, joined with PrefixSeparator
 
-	opts Options
+	opts  Options
+	given *Options // the options passed to NewHandler, for WithOptions
+
+	start      *atomic.Pointer[time.Time] // creation time, for TimeFormatRelative, shared with clones
+	levelPad   int                        // minimum width of the level labels
+	srcBaseDir string                     // normalized SrcBaseDir
+	attrOrder  [][]string                 // AttrOrder split at the dots
+	redactKeys map[string]struct{}        // lower-case RedactKeys
+	highlights []keyPattern               // HighlightKeyPatterns sorted by pattern
+	colors     map[*color.Color][2]string // escape sequences of the colors, see collectColors
+	sampler    *sampler                   // not shared with clones
+	flusher    *flusher                   // buffers out if BufferSize is set
+	hooks      *hookPool                  // calls AfterHandle if AsyncHook is set
 
 	mu  *sync.Mutex
 	out io.Writer
+	dst io.Writer // the writer passed to NewHandler, out unless buffered
 }
 
 // NewHandler creates a new [Handler] with the specified options. If opts is nil, uses [DefaultOptions].
+// The options are copied with [Options.Clone], so changing them afterwards does not affect the handler.
 func NewHandler(out io.Writer, opts *Options) *Handler {
-	h := &Handler{out: out, mu: &sync.Mutex{}}
+	h := &Handler{out: out, dst: out, mu: &sync.Mutex{}, start: &atomic.Pointer[time.Time]{}}
+	h.SetStartTime(time.Now())
 	if opts == nil {
-		h.opts = *DefaultOptions
-	} else {
-		h.opts = *opts
+		opts = DefaultOptions
+	}
+	h.given = opts.Clone()
+	h.opts = *opts.Clone()
+	if h.opts.Level == nil {
+		h.opts.Level = slog.LevelInfo
+	}
+	h.srcBaseDir = normalizeBaseDir(h.opts.SrcBaseDir)
+	for _, key := range h.opts.AttrOrder {
+		h.attrOrder = append(h.attrOrder, strings.Split(key, "."))
+	}
+	for _, key := range h.opts.RedactKeys {
+		if h.redactKeys == nil {
+			h.redactKeys = make(map[string]struct{}, len(h.opts.RedactKeys))
+		}
+		h.redactKeys[strings.ToLower(key)] = struct{}{}
+	}
+	for re, c := range h.opts.HighlightKeyPatterns {
+		h.highlights = append(h.highlights, keyPattern{re: re, color: c})
+	}
+	slices.SortFunc(h.highlights, func(a, b keyPattern) int {
+		return strings.Compare(a.re.String(), b.re.String())
+	})
+	if h.opts.TimeFormat == "" {
+		h.opts.TimeFormat = defaultTimeFormat
+	}
+	h.opts.TimeFormat = withPrecision(h.opts.TimeFormat, h.opts.TimePrecision)
+	if h.opts.EllipsisStyle == "" {
+		h.opts.EllipsisStyle = defaultEllipsis
+	}
+	if h.opts.PrefixSeparator == "" {
+		h.opts.PrefixSeparator = " "
+	}
+	if h.opts.FieldSeparator == "" {
+		h.opts.FieldSeparator = " "
+	}
+	if h.opts.ColumnSeparator == "" {
+		h.opts.ColumnSeparator = " "
+	}
+	if h.opts.AttrIndent == "" {
+		h.opts.AttrIndent = " "
+	}
+	if h.opts.MultilineIndent == "" {
+		h.opts.MultilineIndent = "    "
+	}
+	if h.opts.LineTerminator == "" {
+		h.opts.LineTerminator = "\n"
+	}
+	if h.opts.UTC && h.opts.TimeLocation == nil {
+		h.opts.TimeLocation = time.UTC
+	}
+	if h.opts.NoTime {
+		h.opts.OmitFields |= OmitTime
+	}
+	if !h.opts.SkipWindowsInit && !enableWindowsConsole(out) && !h.opts.ForceColor {
+		h.opts.NoColor = true // a legacy Windows console would print the escape sequences
+	}
+	if !colorSupported(out, h.opts.ForceColor) || h.opts.Format != FormatColor {
+		h.opts.NoColor = true
+	}
+	if h.opts.Format == FormatText {
+		h.opts.QuoteValues = true
+	}
+	if h.opts.Width == 0 {
+		h.opts.Width = terminalWidth(out)
 	}
 
-	tags := maps.Clone(DefaultLevelTags)
-	if opts.LevelTags != nil {
-		for k, v := range opts.LevelTags {
-			tags[k] = v
+	if h.opts.ColorProfile == ColorProfileAuto {
+		h.opts.ColorProfile = detectColorProfile()
+		switch {
+		case h.opts.TrueColor:
+			h.opts.ColorProfile = ColorProfileTrueColor
+		case h.opts.ColorProfile == ColorProfileNoColor && h.opts.ForceColor:
+			h.opts.ColorProfile = ColorProfileANSI16
 		}
 	}
+	switch h.opts.ColorProfile {
+	case ColorProfileNoColor:
+		h.opts.NoColor = true
+	case ColorProfileTrueColor:
+		h.opts.TrueColor = true
+	}
+
+	if h.opts.Theme == nil {
+		h.opts.Theme = ThemeDefault
+		if h.opts.TrueColor {
+			h.opts.Theme = ThemeTrueColor
+		}
+	}
+	if h.opts.SourceColor != nil {
+		for _, c := range []**color.Color{&h.opts.SrcFileColor, &h.opts.SrcFuncColor} {
+			if *c == nil {
+				*c = h.opts.SourceColor
+			}
+		}
+	}
+	// colors which are not set fall back to the theme
+	for _, c := range []struct{ opt, theme **color.Color }{
+		{&h.opts.PrefixColor, &h.opts.Theme.Prefix},
+		{&h.opts.GoroutineIDColor, &h.opts.Theme.Time},
+		{&h.opts.SrcFileColor, &h.opts.Theme.Source},
+		{&h.opts.SrcFuncColor, &h.opts.Theme.Func},
+		{&h.opts.MsgColor, &h.opts.Theme.Message},
+		{&h.opts.KeyColor, &h.opts.Theme.Key},
+		{&h.opts.ValueColor, &h.opts.Theme.Value},
+		{&h.opts.StringColor, &h.opts.Theme.String},
+		{&h.opts.NumberColor, &h.opts.Theme.Number},
+		{&h.opts.BoolColor, &h.opts.Theme.Bool},
+		{&h.opts.NullColor, &h.opts.Theme.Null},
+		{&h.opts.TimeValueColor, &h.opts.Theme.TimeValue},
+		{&h.opts.ErrorColor, &h.opts.Theme.Error},
+	} {
+		if *c.opt == nil {
+			*c.opt = *c.theme
+		}
+	}
+
+	h.mergeCustomLevels()
+	h.collectColors()
+	h.levelPad = defaultLevelWidth
+	if h.opts.PadLevelText || h.opts.LevelIcons != nil {
+		h.levelPad = h.longestLevelLabel()
+	}
+
+	tags := make(map[slog.Level]string)
+	for k := range h.opts.Theme.Levels {
+		tags[k] = h.buildLevelTag(k)
+	}
+	for k := range h.opts.LevelColors {
+		tags[k] = h.buildLevelTag(k)
+	}
+	for k := range h.opts.LevelLabels {
+		tags[k] = h.buildLevelTag(k)
+	}
+	for k := range h.opts.LevelIcons {
+		tags[k] = h.buildLevelTag(k)
+	}
+	for k, v := range h.opts.LevelTags {
+		tags[k] = v
+	}
 	h.opts.LevelTags = tags
+	h.sampler = h.newSampler()
+
+	if h.opts.BufferSize > 0 {
+		if h.opts.FlushInterval <= 0 {
+			h.opts.FlushInterval = defaultFlushInterval
+		}
+		h.flusher = newFlusher(out, h.mu, h.opts.BufferSize, h.opts.FlushInterval)
+		h.out = h.flusher.w
+	}
+	if h.opts.AfterHandle != nil && h.opts.AsyncHook {
+		if h.opts.HookWorkers <= 0 {
+			h.opts.HookWorkers = 1
+		}
+		h.hooks = newHookPool(h.opts.HookWorkers)
+	}
 
 	return h
 }
 
 func (h *Handler) clone() *Handler {
 	return &Handler{
-		groups: h.groups,
-		attrs:  h.attrs,
-		opts:   h.opts,
-		mu:     h.mu,
-		out:    h.out,
+		groups:     h.groups,
+		attrs:      h.attrs,
+		prefix:     h.prefix,
+		opts:       h.opts,
+		given:      h.given,
+		start:      h.start,
+		levelPad:   h.levelPad,
+		srcBaseDir: h.srcBaseDir,
+		attrOrder:  h.attrOrder,
+		redactKeys: h.redactKeys,
+		highlights: h.highlights,
+		colors:     h.colors,
+		sampler:    h.newSampler(),
+		flusher:    h.flusher,
+		hooks:      h.hooks,
+		mu:         h.mu,
+		out:        h.out,
+		dst:        h.dst,
 	}
 }
 
@@ -62,129 +233,277 @@ func (h *Handler) Enabled(_ context.Context, level slog.Level) bool {
 	return level >= h.opts.Level.Level()
 }
 
-// Handle implements slog.Handler.Handle .
-func (h *Handler) Handle(_ context.Context, r slog.Record) error {
+// SetLevel sets the minimum level of h and all its clones. It panics if [Options.Level] is not a [*slog.LevelVar].
+func (h *Handler) SetLevel(l slog.Level) {
+	v, ok := h.opts.Level.(*slog.LevelVar)
+	if !ok {
+		panic("slogcolor: SetLevel called on a handler without a *slog.LevelVar as Options.Level")
+	}
+	v.Set(l)
+}
+
+// Handle implements slog.Handler.Handle . It returns the error of writing the record, or [io.ErrShortWrite]
+// if the writer wrote only part of it without an error.
+func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
+	if !h.sample(r.Level, r.Message) {
+		return nil
+	}
+
 	bf := getBuffer()
 	bf.Reset()
 
-	if !h.opts.NoTime && !r.Time.IsZero() {
-		fmt.Fprint(bf, color.New(color.Faint).Sprint(r.Time.Format(h.opts.TimeFormat)))
-		fmt.Fprint(bf, " ")
+	if h.opts.Format == FormatLogfmt {
+		h.writeLogfmt(ctx, bf, r)
+	} else {
+		h.writeRecord(ctx, bf, r)
+	}
+
+	if !h.opts.NoLineTerminator {
+		bf.WriteString(h.opts.LineTerminator)
 	}
 
-	fmt.Fprint(bf, h.opts.LevelTags[r.Level])
+	h.adaptColors(bf)
 
-	fmt.Fprint(bf, " ")
+	// the whole record is written at once, and the mutex is shared by all clones of the handler,
+	// so records logged concurrently are never interleaved
+	h.mu.Lock()
+	n, err := h.out.Write(bf.Bytes())
+	if err == nil && n < bf.Len() {
+		err = io.ErrShortWrite // a writer breaking the contract of io.Writer
+	}
+	if err == nil && h.opts.FlushEachRecord {
+		err = h.flushRecord()
+	}
+	h.mu.Unlock()
 
-	if h.opts.SrcFileMode != Nop {
-		if r.PC != 0 {
-			f, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+	freeBuffer(bf)
 
-			var filename string
-			switch h.opts.SrcFileMode {
-			case Nop:
-				break
-			case ShortFile:
-				filename = filepath.Base(f.File)
-			case MediumFile:
-				filename = h.getRelativePath(f.File)
-			case LongFile:
-				filename = f.File
-			}
-			lineStr := fmt.Sprintf(":%d", f.Line)
-			formatted := fmt.Sprintf("%s ", filename+lineStr)
-			if h.opts.SrcFileLength > 0 {
-				maxFilenameLen := h.opts.SrcFileLength - len(lineStr) - 1
-				if len(filename) > maxFilenameLen {
-					filename = filename[:maxFilenameLen] // Truncate if too long
-				}
-				lenStr := strconv.Itoa(h.opts.SrcFileLength)
-				formatted = fmt.Sprintf("%-"+lenStr+"s", filename+lineStr)
-			}
-			if h.opts.SrcFileColor == nil {
-				h.opts.SrcFileColor = color.New() // set to empty otherwise we have a null pointer
+	if h.opts.AfterHandle != nil {
+		h.afterHandle(r, err)
+	}
+	return err
+}
+
+// writeRecord writes r to bf in the colored format, without the trailing newline.
+func (h *Handler) writeRecord(ctx context.Context, bf *bytes.Buffer, r slog.Record) {
+	if h.prefix != "" {
+		h.writeColor(bf, h.opts.PrefixColor, h.prefix)
+		bf.WriteString(" ")
+	}
+
+	if h.opts.OmitFields&OmitTime == 0 && !r.Time.IsZero() {
+		t := r.Time
+		if h.opts.TimeLocation != nil {
+			t = t.In(h.opts.TimeLocation)
+		}
+		if v, ok := h.replaceBuiltin(slog.Time(slog.TimeKey, t)); ok {
+			start, end := h.colorSequences(h.opts.Theme.Time)
+			bf.WriteString(start)
+			bf.Write(h.appendTime(bf.AvailableBuffer(), v))
+			bf.WriteString(end)
+			h.writeColumnSeparator(bf)
+		}
+	}
+
+	if h.opts.ShowGoroutineID {
+		start, end := h.colorSequences(h.opts.GoroutineIDColor)
+		bf.WriteString(start)
+		bf.Write(appendGoroutineID(bf.AvailableBuffer()))
+		bf.WriteString(end)
+		h.writeColumnSeparator(bf)
+	}
+
+	if h.opts.OmitFields&OmitLevel == 0 {
+		if v, ok := h.replaceBuiltin(slog.Any(slog.LevelKey, r.Level)); ok {
+			if l, isLevel := v.Any().(slog.Level); isLevel {
+				bf.WriteString(h.levelTag(l))
+			} else {
+				h.writeColor(bf, h.levelColor(r.Level), h.padLevelLabel(v.String()))
 			}
-			fmt.Fprint(bf, h.opts.SrcFileColor.Sprint(formatted))
+			h.writeColumnSeparator(bf)
+		}
+	}
+
+	srcStart := bf.Len()
+	if h.opts.SrcPosition == SrcPositionAfterLevel {
+		h.writeSourceField(bf, r)
+		// the source ends with a space, unless it is padded to SrcFileLength
+		if bf.Len() > srcStart && h.customColumnSeparator() {
+			trimTrailingSpace(bf, srcStart)
+			h.writeColumnSeparator(bf)
 		}
 	}
+	srcEnd := bf.Len()
+
+	omitMessage := h.opts.OmitFields&OmitMessage != 0
+	var msg string
+	if !omitMessage {
+		v, ok := h.replaceBuiltin(slog.String(slog.MessageKey, r.Message))
+		msg, omitMessage = v.String(), !ok
+	}
 
 	// we need the attributes here, as we can print a longer string if there are no attributes
-	var attrs []slog.Attr
-	attrs = append(attrs, h.attrs...)
-	r.Attrs(func(a slog.Attr) bool {
-		attrs = append(attrs, a)
-		return true
-	})
+	attrsp := getAttrs()
+	defer freeAttrs(attrsp)
+	attrs := h.collectAttrs(ctx, attrsp, r)
+	attrs, stacks := h.splitStacks(attrs)
 
-	fmt.Fprint(bf, h.opts.MsgPrefix)
-	formattedMessage := r.Message
-	if h.opts.MsgLength > 0 && len(attrs) > 0 {
-		if len(formattedMessage) > h.opts.MsgLength {
-			formattedMessage = formattedMessage[:h.opts.MsgLength-1] + "…" // Truncate and add ellipsis if too long
+	msgStart := bf.Len()
+	if !omitMessage {
+		if h.opts.MsgPrefix != "" {
+			h.writeColor(bf, h.opts.MsgPrefixColor, h.opts.MsgPrefix)
+			msgStart = bf.Len()
+		}
+		formattedMessage := msg
+		if h.opts.EscapeNewlines && strings.ContainsAny(formattedMessage, "\r\n") {
+			formattedMessage = newlineEscaper.Replace(formattedMessage)
+		}
+		if h.opts.MaxMessageLength > 0 {
+			formattedMessage = h.truncateMessage(formattedMessage)
+		}
+		if h.opts.MsgLength > 0 && len(attrs) > 0 {
+			// Truncate with an ellipsis if too long, pad with spaces if too short
+			formattedMessage = fitWidth(formattedMessage, h.opts.MsgLength)
+		}
+		h.writeColor(bf, h.opts.MsgColor, formattedMessage)
+	}
+
+	if !h.opts.Multiline && h.opts.Layout != LayoutExpanded {
+		h.writeAttrs(bf, attrs, omitMessage, msgStart)
+	}
+	if h.opts.SrcPosition == SrcPositionAfterAttrs {
+		srcStart = bf.Len()
+		h.writeColumnSeparator(bf)
+		sepEnd := bf.Len()
+		h.writeSourceField(bf, r)
+		if trimTrailingSpace(bf, sepEnd) {
+			srcEnd = bf.Len()
 		} else {
-			// Pad with spaces if too short
-			lenStr := strconv.Itoa(h.opts.MsgLength)
-			formattedMessage = fmt.Sprintf("%-"+lenStr+"s", formattedMessage)
+			bf.Truncate(srcStart) // no source
+			srcEnd = srcStart
 		}
 	}
-	if h.opts.MsgColor == nil {
-		h.opts.MsgColor = color.New() // set to empty otherwise we have a null pointer
+	if h.opts.MaxLineWidth > 0 {
+		h.fitLine(bf, srcStart, srcEnd, bf.Len())
+	}
+	switch {
+	case h.opts.Multiline:
+		h.writeMultilineAttrs(bf, attrs)
+	case h.opts.Layout == LayoutExpanded:
+		h.writeExpandedAttrs(bf, attrs)
+	}
+	if h.opts.UnwrapErrors {
+		h.writeCauses(bf, attrs)
 	}
-	fmt.Fprintf(bf, "%s", h.opts.MsgColor.Sprint(formattedMessage))
+	h.writeStacks(bf, stacks)
+	if h.opts.Multiline && h.opts.MultilineSeparator != "" {
+		bf.WriteString("\n" + h.opts.MultilineSeparator)
+	}
+}
 
-	for _, a := range attrs {
-		fmt.Fprint(bf, " ")
-		for i, g := range h.groups {
-			fmt.Fprint(bf, color.New(color.FgCyan).Sprint(g))
-			if i != len(h.groups) {
-				fmt.Fprint(bf, color.New(color.FgCyan).Sprint("."))
-			}
-		}
+// writeColumnSeparator writes [Options.ColumnSeparator] to bf.
+func (h *Handler) writeColumnSeparator(bf *bytes.Buffer) {
+	h.writeColor(bf, h.opts.SeparatorColor, h.opts.ColumnSeparator)
+}
+
+// customColumnSeparator reports whether the space after the source has to be replaced with [Options.ColumnSeparator].
+func (h *Handler) customColumnSeparator() bool {
+	return h.opts.ColumnSeparator != " " || h.opts.SeparatorColor != nil
+}
 
-		if strings.Contains(a.Key, "err") {
-			fmt.Fprint(bf, color.New(color.FgRed).Sprintf("%s=", a.Key)+a.Value.String())
+// writeSourceField writes the source of r to bf, followed by a space, unless it is omitted.
+func (h *Handler) writeSourceField(bf *bytes.Buffer, r slog.Record) {
+	if h.opts.OmitFields&OmitSource != 0 {
+		return
+	}
+	src := h.source(r.PC, r.Level)
+	if src == nil {
+		return
+	}
+	if v, ok := h.replaceBuiltin(slog.Any(slog.SourceKey, src)); ok {
+		if src, isSource := v.Any().(*slog.Source); isSource {
+			h.writeSource(bf, src)
 		} else {
-			fmt.Fprint(bf, color.New(color.FgCyan).Sprintf("%s=", a.Key)+a.Value.String())
+			h.writeColor(bf, h.opts.SrcFileColor, v.String(), " ")
 		}
 	}
+}
 
-	fmt.Fprint(bf, "\n")
-
-	if h.opts.NoColor {
-		stripANSI(bf)
+// trimTrailingSpace removes the last visible character of bf[start:] if it is a space, keeping the escape
+// sequences after it, and reports whether it did.
+func trimTrailingSpace(bf *bytes.Buffer, start int) bool {
+	b := bf.Bytes()
+	i := bytes.LastIndexByte(b[start:], ' ')
+	if i < 0 {
+		return false
+	}
+	i += start
+	for j := i + 1; j < len(b); {
+		l := escapeLen(b[j:])
+		if l == 0 {
+			return false
+		}
+		j += l
 	}
+	copy(b[i:], b[i+1:])
+	bf.Truncate(len(b) - 1)
+	return true
+}
 
-	h.mu.Lock()
-	_, err := io.Copy(h.out, bf)
-	h.mu.Unlock()
+// WithPrefix returns a new [Handler] which prints prefix before the timestamp of every log line,
+// colored with [Options.PrefixColor]. The prefixes of repeated calls are joined with [Options.PrefixSeparator].
+func (h *Handler) WithPrefix(prefix string) *Handler {
+	if prefix == "" {
+		return h
+	}
+	h2 := h.clone()
+	if h2.prefix != "" {
+		h2.prefix += h.opts.PrefixSeparator
+	}
+	h2.prefix += prefix
+	return h2
+}
 
-	freeBuffer(bf)
+// WithOptions returns a new [Handler] with the options of h changed by fn, for example to lower the level
+// for one subsystem. fn is called with a deep copy of the options passed to [NewHandler], or to the WithOptions
+// call which created h, so changing them does not affect h. The new handler writes to the same output, keeps
+// the groups, attributes and prefixes of h, and shares its buffer and the workers of AsyncHook, if any: BufferSize,
+// FlushInterval, AsyncHook and HookWorkers cannot be changed. An AfterHandle set by fn is called directly if h has
+// no workers. The attributes already added to h are not formatted again, for example with a new ReplaceAttr.
+func (h *Handler) WithOptions(fn func(*Options)) *Handler {
+	opts := h.given.Clone()
+	fn(opts)
+	opts.BufferSize, opts.FlushInterval = h.given.BufferSize, h.given.FlushInterval
+	opts.AsyncHook, opts.HookWorkers = h.given.AsyncHook, h.given.HookWorkers
 
-	return err
+	unbuffered := *opts
+	unbuffered.BufferSize, unbuffered.AsyncHook = 0, false
+	h2 := NewHandler(h.dst, &unbuffered)
+	h2.given = opts
+	h2.opts.BufferSize, h2.opts.FlushInterval = h.opts.BufferSize, h.opts.FlushInterval
+	h2.opts.AsyncHook, h2.opts.HookWorkers = h.opts.AsyncHook, h.opts.HookWorkers
+	h2.groups, h2.attrs, h2.prefix = h.groups, h.attrs, h.prefix
+	h2.start, h2.flusher, h2.mu, h2.out = h.start, h.flusher, h.mu, h.out
+	h2.hooks = h.hooks
+	return h2
 }
 
 // WithGroup implements slog.Handler.WithGroup .
 func (h *Handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
 	h2 := h.clone()
-	h2.groups = append(h2.groups, name)
+	h2.groups = append(slices.Clip(h2.groups), name)
 	return h2
 }
 
 // WithAttrs implements slog.Handler.WithAttrs .
 func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
 	h2 := h.clone()
-	h2.attrs = append(h2.attrs, attrs...)
-	return h2
-}
-
-// getRelativePath returns the file path relative to the project root
-func (h *Handler) getRelativePath(fullPath string) string {
-	// Try to get the working directory (project root)
-	if wd, err := os.Getwd(); err == nil {
-		if relPath, err := filepath.Rel(wd, fullPath); err == nil {
-			return relPath
-		}
+	h2.attrs = slices.Clip(h2.attrs)
+	for _, a := range attrs {
+		h2.attrs = h.appendAttr(h2.attrs, h.groups, a)
 	}
-	// Fallback to full path if we can't determine relative path
-	return fullPath
+	return h2
 }
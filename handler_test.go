@@ -1,14 +1,25 @@
 package slogcolor_test
 
 import (
+	"bytes"
+	"context"
 	"errors"
+	"fmt"
+	"io"
 	"log/slog"
 	"os"
+	"reflect"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
 	"time"
+	"unicode/utf8"
 
-	"github.com/geomyidia/slogcolor"
 	"github.com/fatih/color"
+	"github.com/geomyidia/slogcolor"
 )
 
 func Example() {
@@ -31,13 +42,2229 @@ func Example() {
 	slog.Info("Message without time")
 }
 
-func BenchmarkLog(b *testing.B) {
-	b.StopTimer()
-	l := slog.New(slogcolor.NewHandler(os.Stderr, slogcolor.DefaultOptions))
+func TestReplaceAttr(t *testing.T) {
+	var gotGroups [][]string
+	var buf bytes.Buffer
+	h := slogcolor.NewHandler(&buf, &slogcolor.Options{
+		Level:   slog.LevelInfo,
+		NoColor: true,
+		NoTime:  true,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			gotGroups = append(gotGroups, groups)
+			switch a.Key {
+			case "password":
+				return slog.String(a.Key, "***")
+			case "drop":
+				return slog.Attr{}
+			}
+			return a
+		},
+	})
+	l := slog.New(h).With("user", "bob").WithGroup("req").With("password", "hunter2")
+	l.Info("login", "drop", true, "id", 1)
+
+	want := "INFO  login user=bob req.password=*** req.id=1\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	wantGroups := [][]string{nil, {"req"}, nil, nil, {"req"}, {"req"}} // user, password, level, msg, drop, id
+	if !reflect.DeepEqual(gotGroups, wantGroups) {
+		t.Errorf("got groups %v, want %v", gotGroups, wantGroups)
+	}
+}
+
+func TestLevelColors(t *testing.T) {
+	cyan := color.New(color.FgCyan)
+
+	var buf bytes.Buffer
+	h := slogcolor.NewHandler(&buf, &slogcolor.Options{
+		Level:       slog.LevelDebug,
+		NoTime:      true,
+		ForceColor:  true,
+		LevelColors: map[slog.Level]*color.Color{slog.LevelDebug: cyan},
+		LevelTags:   map[slog.Level]string{slog.LevelWarn: "WRN"},
+	})
+	l := slog.New(h)
+	l.Debug("debug")
+	l.Warn("warn")
+
+	want := "\x1b[36mDEBUG\x1b[0m debug\nWRN warn\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestLevelColorsCustomLevel(t *testing.T) {
+	const levelCritical = slog.Level(12)
+
+	var buf bytes.Buffer
+	h := slogcolor.NewHandler(&buf, &slogcolor.Options{
+		Level:       slog.LevelInfo,
+		NoTime:      true,
+		ForceColor:  true,
+		Theme:       &slogcolor.Theme{Levels: map[slog.Level]*color.Color{slog.LevelWarn: color.New(color.FgYellow)}},
+		LevelColors: map[slog.Level]*color.Color{levelCritical: color.New(color.FgRed)},
+		LevelLabels: map[slog.Level]string{levelCritical: "CRITICAL"},
+	})
+	l := slog.New(h)
+	l.Log(context.Background(), levelCritical, "down")
+	l.Log(context.Background(), slog.LevelWarn+2, "slow")
+
+	want := "\x1b[31mCRITICAL\x1b[0m down\n\x1b[33mWARN+2\x1b[0m slow\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestNoTime(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		opts slogcolor.Options
+		want string
+	}{
+		{"color", slogcolor.Options{ForceColor: true, Theme: &slogcolor.Theme{}}, "INFO  msg k=v\n"},
+		{"plain", slogcolor.Options{NoColor: true}, "INFO  msg k=v\n"},
+		{"logfmt", slogcolor.Options{Format: slogcolor.FormatLogfmt}, "level=INFO msg=msg k=v\n"},
+	} {
+		var buf bytes.Buffer
+		opts := tt.opts
+		opts.Level = slog.LevelInfo
+		opts.NoTime = true
+		opts.TimeFormat = time.RFC3339
+		slog.New(slogcolor.NewHandler(&buf, &opts)).Info("msg", "k", "v")
+		if got := buf.String(); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestColorDetection(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	slog.New(slogcolor.NewHandler(w, &slogcolor.Options{Level: slog.LevelInfo, NoTime: true})).Info("piped")
+	w.Close()
+	piped, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "INFO  piped\n"; string(piped) != want {
+		t.Errorf("pipe: got %q, want %q", piped, want)
+	}
+
+	for _, tt := range []struct {
+		name      string
+		opts      slogcolor.Options
+		wantColor bool
+	}{
+		{"default", slogcolor.Options{}, false},
+		{"ForceColor", slogcolor.Options{ForceColor: true}, true},
+		{"NoColor", slogcolor.Options{ForceColor: true, NoColor: true}, false},
+	} {
+		var buf bytes.Buffer
+		opts := tt.opts
+		opts.NoTime = true
+		slog.New(slogcolor.NewHandler(&buf, &opts)).Error("buffered", "err", io.EOF)
+		if got := strings.Contains(buf.String(), "\x1b["); got != tt.wantColor {
+			t.Errorf("buffer with %s: got color %v, want %v (%q)", tt.name, got, tt.wantColor, buf.String())
+		}
+	}
+}
+
+func TestNoColorEnv(t *testing.T) {
+	for _, tt := range []struct {
+		value     string
+		wantColor bool
+	}{
+		{"", true},
+		{"1", false},
+		{"true", false},
+	} {
+		t.Run(tt.value, func(t *testing.T) {
+			t.Setenv("NO_COLOR", tt.value)
+			var buf bytes.Buffer
+			h := slogcolor.NewHandler(&buf, &slogcolor.Options{Level: slog.LevelInfo, NoTime: true, ForceColor: true})
+			os.Unsetenv("NO_COLOR") // only checked when the handler is created
+
+			slog.New(h).Info("msg")
+			if got := strings.Contains(buf.String(), "\x1b["); got != tt.wantColor {
+				t.Errorf("NO_COLOR=%q: got color %v, want %v (%q)", tt.value, got, tt.wantColor, buf.String())
+			}
+		})
+	}
+}
+
+func TestGroups(t *testing.T) {
+	var buf, text bytes.Buffer
+	h := slogcolor.NewHandler(&buf, &slogcolor.Options{Level: slog.LevelInfo, NoColor: true, NoTime: true})
+	th := slog.NewTextHandler(&text, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if len(groups) == 0 && (a.Key == slog.TimeKey || a.Key == slog.LevelKey || a.Key == slog.MessageKey) {
+				return slog.Attr{}
+			}
+			return a
+		},
+	})
+
+	for _, h := range []slog.Handler{h, th} {
+		l := slog.New(h).With("app", "srv").WithGroup("http").WithGroup("").With("method", "GET").WithGroup("req")
+		l.Info("x", "status", 200, slog.Group("client", "ip", "::1", slog.Group("empty")), slog.Group("", "inlined", true))
+	}
+
+	want := "INFO  x " + text.String()
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestTrueColor(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		colorterm string
+		opts      slogcolor.Options
+		want      string
+	}{
+		{"default", "", slogcolor.Options{}, "\x1b[42;97mINFO "},
+		{"option", "", slogcolor.Options{TrueColor: true}, "\x1b[48;2;152;195;121;38;2;40;44;52mINFO "},
+		{"COLORTERM", "truecolor", slogcolor.Options{}, "\x1b[48;2;152;195;121;38;2;40;44;52mINFO "},
+		{"custom", "", slogcolor.Options{
+			LevelColors:  map[slog.Level]*color.Color{slog.LevelInfo: slogcolor.RGBColor{1, 2, 3}.Fg()},
+			ColorProfile: slogcolor.ColorProfileTrueColor,
+		}, "\x1b[38;2;1;2;3mINFO "},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("COLORTERM", tt.colorterm)
+			var buf bytes.Buffer
+			opts := tt.opts
+			opts.Level = slog.LevelInfo
+			opts.NoTime = true
+			opts.ForceColor = true
+			slog.New(slogcolor.NewHandler(&buf, &opts)).Info("msg")
+			if !strings.HasPrefix(buf.String(), tt.want) {
+				t.Errorf("got %q, want prefix %q", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestColorProfile(t *testing.T) {
+	levelColors := map[slog.Level]*color.Color{
+		slog.LevelInfo: slogcolor.RGBColor{250, 10, 10}.Bg().AddRGB(20, 20, 20),
+		slog.LevelWarn: color.New(38, 5, 226),
+	}
+	for _, tt := range []struct {
+		name      string
+		profile   slogcolor.ColorProfile
+		colorterm string
+		term      string
+		want      string
+	}{
+		{"TrueColor", slogcolor.ColorProfileTrueColor, "", "", "\x1b[48;2;250;10;10;38;2;20;20;20mINFO \x1b[0;22;0;0;0;0;22;0;0;0m msg\n" +
+			"\x1b[38;5;226mWARN \x1b[0;25;0m msg\n"},
+		{"ANSI256", slogcolor.ColorProfileANSI256, "", "", "\x1b[48;5;196;38;5;233mINFO \x1b[0;22;0;0;0;0;22;0;0;0m msg\n" +
+			"\x1b[38;5;226mWARN \x1b[0;25;0m msg\n"},
+		{"ANSI16", slogcolor.ColorProfileANSI16, "", "", "\x1b[101;30mINFO \x1b[0;22;0;0;0;0;22;0;0;0m msg\n" +
+			"\x1b[93mWARN \x1b[0;25;0m msg\n"},
+		{"NoColor", slogcolor.ColorProfileNoColor, "", "", "INFO  msg\nWARN  msg\n"},
+		{"auto truecolor", slogcolor.ColorProfileAuto, "truecolor", "xterm", "\x1b[48;2;250;10;10;38;2;20;20;20mINFO \x1b[0;22;0;0;0;0;22;0;0;0m msg\n" +
+			"\x1b[38;5;226mWARN \x1b[0;25;0m msg\n"},
+		{"auto 256", slogcolor.ColorProfileAuto, "", "xterm-256color", "\x1b[48;5;196;38;5;233mINFO \x1b[0;22;0;0;0;0;22;0;0;0m msg\n" +
+			"\x1b[38;5;226mWARN \x1b[0;25;0m msg\n"},
+		{"auto 16", slogcolor.ColorProfileAuto, "", "xterm", "\x1b[101;30mINFO \x1b[0;22;0;0;0;0;22;0;0;0m msg\n" +
+			"\x1b[93mWARN \x1b[0;25;0m msg\n"},
+		{"auto dumb", slogcolor.ColorProfileAuto, "", "dumb", "\x1b[101;30mINFO \x1b[0;22;0;0;0;0;22;0;0;0m msg\n" +
+			"\x1b[93mWARN \x1b[0;25;0m msg\n"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("COLORTERM", tt.colorterm)
+			t.Setenv("TERM", tt.term)
+			var buf bytes.Buffer
+			l := slog.New(slogcolor.NewHandler(&buf, &slogcolor.Options{
+				Level:        slog.LevelInfo,
+				NoTime:       true,
+				ForceColor:   true,
+				LevelColors:  levelColors,
+				ColorProfile: tt.profile,
+			}))
+			l.Info("msg")
+			l.Warn("msg")
+			if got := buf.String(); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLevelVar(t *testing.T) {
+	var lvl slog.LevelVar
+	var buf bytes.Buffer
+	l := slog.New(slogcolor.NewHandler(&buf, &slogcolor.Options{Level: &lvl, NoColor: true, NoTime: true}))
+
+	l.Debug("dropped")
+	l.Info("kept")
+	lvl.Set(slog.LevelDebug)
+	l.Debug("kept")
+	lvl.Set(slog.LevelError)
+	l.Warn("dropped")
+	l.Error("kept")
+
+	want := "INFO  kept\nDEBUG kept\nERROR kept\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestNilLevel(t *testing.T) {
+	h := slogcolor.NewHandler(io.Discard, &slogcolor.Options{})
+	if h.Enabled(context.Background(), slog.LevelDebug) || !h.Enabled(context.Background(), slog.LevelInfo) {
+		t.Error("expected the handler to default to slog.LevelInfo")
+	}
+}
+
+func TestTheme(t *testing.T) {
+	var buf bytes.Buffer
+	slog.New(slogcolor.NewHandler(&buf, &slogcolor.Options{
+		Level:      slog.LevelInfo,
+		NoTime:     true,
+		ForceColor: true,
+		Theme: &slogcolor.Theme{
+			Levels:  map[slog.Level]*color.Color{slog.LevelInfo: color.New(color.FgGreen)},
+			Message: color.New(color.Bold),
+			Key:     color.New(color.FgBlue),
+			Value:   color.New(color.FgYellow),
+		},
+	})).Info("msg", "k", "v", "err", "e")
+
+	want := "\x1b[32mINFO \x1b[0m \x1b[1mmsg\x1b[22m \x1b[34mk=\x1b[0m\x1b[33mv\x1b[0m err=\x1b[33me\x1b[0m\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	for _, theme := range []*slogcolor.Theme{slogcolor.ThemeDefault, slogcolor.ThemeTrueColor, slogcolor.ThemeDracula, slogcolor.ThemeSolarizedDark, slogcolor.ThemeLight, slogcolor.ThemeMonochrome} {
+		buf.Reset()
+		h := slogcolor.NewHandler(&buf, &slogcolor.Options{Level: slog.LevelDebug, Theme: theme, SrcFileMode: slogcolor.ShortFile})
+		l := slog.New(h)
+		l.Debug("debug", "k", "v")
+		l.Info("info", "k", "v")
+		l.Warn("warn", "k", "v")
+		l.Error("error", "err", "e")
+		if strings.Count(buf.String(), "\n") != 4 {
+			t.Errorf("unexpected output %q", buf.String())
+		}
+	}
+}
+
+func TestColorChangedBetweenHandlers(t *testing.T) {
+	c := color.New(color.FgRed)
+	for _, want := range []string{"\x1b[31mmsg", "\x1b[31;1mmsg"} {
+		var buf bytes.Buffer
+		h := slogcolor.NewHandler(&buf, &slogcolor.Options{Level: slog.LevelInfo, ForceColor: true, NoTime: true, MsgColor: c})
+		slog.New(h).Info("msg")
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("got %q, want %q", buf.String(), want)
+		}
+		c.Add(color.Bold) // only affects the handlers created afterwards
+	}
+}
+
+func TestValueColors(t *testing.T) {
+	var buf bytes.Buffer
+	slog.New(slogcolor.NewHandler(&buf, &slogcolor.Options{
+		Level:          slog.LevelInfo,
+		NoTime:         true,
+		ForceColor:     true,
+		Theme:          &slogcolor.Theme{},
+		KeyColor:       color.New(color.FgBlue),
+		ValueColor:     color.New(color.FgWhite),
+		StringColor:    color.New(color.FgGreen),
+		NumberColor:    color.New(color.FgYellow),
+		BoolColor:      color.New(color.FgMagenta),
+		NullColor:      color.New(color.FgHiBlack),
+		TimeValueColor: color.New(color.FgCyan),
+		ErrorColor:     color.New(color.FgRed),
+		ShowErrorType:  true,
+	})).Info("msg", "s", "v", "n", 1, "u", uint64(2), "f", 1.5, "b", true, "nil", nil, "p", []int{1},
+		"t", time.Time{}, "d", time.Second, "err", io.EOF)
+
+	want := "INFO  msg" +
+		" \x1b[34ms=\x1b[0m\x1b[32mv\x1b[0m" +
+		" \x1b[34mn=\x1b[0m\x1b[33m1\x1b[0m" +
+		" \x1b[34mu=\x1b[0m\x1b[33m2\x1b[0m" +
+		" \x1b[34mf=\x1b[0m\x1b[33m1.5\x1b[0m" +
+		" \x1b[34mb=\x1b[0m\x1b[35mtrue\x1b[0m" +
+		" \x1b[34mnil=\x1b[0m\x1b[90m<nil>\x1b[0m" +
+		" \x1b[34mp=\x1b[0m\x1b[37m[1]\x1b[0m" +
+		" \x1b[34mt=\x1b[0m\x1b[36m0001-01-01 00:00:00\x1b[0m" +
+		" \x1b[34md=\x1b[0m\x1b[37m1s\x1b[0m" +
+		" err=\x1b[31mEOF (*errors.errorString)\x1b[0m\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	buf.Reset()
+	slog.New(slogcolor.NewHandler(&buf, &slogcolor.Options{
+		Level:       slog.LevelInfo,
+		NoTime:      true,
+		NoColor:     true,
+		NumberColor: color.New(color.FgYellow),
+		BoolColor:   color.New(color.FgMagenta),
+		NullColor:   color.New(color.FgHiBlack),
+	})).Info("msg", "n", 1, "b", true, "nil", nil)
+	if got, want := buf.String(), "INFO  msg n=1 b=true nil=<nil>\n"; got != want {
+		t.Errorf("NoColor: got %q, want %q", got, want)
+	}
+}
+
+func TestThemeMonochrome(t *testing.T) {
+	var buf bytes.Buffer
+	l := slog.New(slogcolor.NewHandler(&buf, &slogcolor.Options{
+		Level:       slog.LevelDebug,
+		ForceColor:  true,
+		Theme:       slogcolor.ThemeMonochrome,
+		SrcFileMode: slogcolor.ShortFile,
+		MsgPrefix:   "| ",
+	}))
+	l.Debug("debug", "k", "v")
+	l.Info("info", "k", 1)
+	l.Warn("warn", "k", true)
+	l.Error("error", "err", io.EOF)
+	l.WithGroup("g").Info("group", "k", "v")
+
+	// only the parameters for bold, dim and underline and their resets are used
+	for _, seq := range regexp.MustCompile(`\x1b\[([0-9;]*)m`).FindAllStringSubmatch(buf.String(), -1) {
+		for _, p := range strings.Split(seq[1], ";") {
+			if p != "0" && p != "1" && p != "2" && p != "4" && p != "22" && p != "24" {
+				t.Errorf("unexpected escape sequence %q in %q", seq[0], buf.String())
+			}
+		}
+	}
+}
+
+type server struct{}
+
+func (*server) serve(l *slog.Logger) { l.Info("serving") }
+
+func TestFuncName(t *testing.T) {
+	for _, tt := range []struct {
+		fileMode, funcMode slogcolor.SourceFileMode
+		want               string
+	}{
+		{slogcolor.FuncName, slogcolor.Nop, "INFO  github.com/geomyidia/slogcolor_test.(*server).serve serving\n"},
+		{slogcolor.FuncShortName, slogcolor.Nop, "INFO  (*server).serve serving\n"},
+		{slogcolor.ShortFile, slogcolor.FuncShortName, "INFO  (*server).serve handler_test.go:"},
+	} {
+		var buf bytes.Buffer
+		(&server{}).serve(slog.New(slogcolor.NewHandler(&buf, &slogcolor.Options{
+			Level:       slog.LevelInfo,
+			NoColor:     true,
+			NoTime:      true,
+			SrcFileMode: tt.fileMode,
+			SrcFuncMode: tt.funcMode,
+		})))
+		if got := buf.String(); !strings.HasPrefix(got, tt.want) {
+			t.Errorf("got %q, want prefix %q", got, tt.want)
+		}
+	}
+}
+
+func TestOmitFields(t *testing.T) {
+	for _, tt := range []struct {
+		omit slogcolor.OmitField
+		want string
+	}{
+		{slogcolor.OmitTime | slogcolor.OmitSource, "INFO  | msg k=v\n"},
+		{slogcolor.OmitTime | slogcolor.OmitSource | slogcolor.OmitLevel, "| msg k=v\n"},
+		{slogcolor.OmitTime | slogcolor.OmitLevel, "handler_test.go:"},
+		{slogcolor.OmitTime | slogcolor.OmitSource | slogcolor.OmitLevel | slogcolor.OmitMessage, "k=v\n"},
+		{slogcolor.OmitTime | slogcolor.OmitSource | slogcolor.OmitMessage, "INFO  k=v\n"},
+	} {
+		var buf bytes.Buffer
+		slog.New(slogcolor.NewHandler(&buf, &slogcolor.Options{
+			Level:       slog.LevelInfo,
+			NoColor:     true,
+			SrcFileMode: slogcolor.ShortFile,
+			MsgPrefix:   "| ",
+			OmitFields:  tt.omit,
+		})).Info("msg", "k", "v")
+		if got := buf.String(); !strings.HasPrefix(got, tt.want) {
+			t.Errorf("OmitFields %b: got %q, want prefix %q", tt.omit, got, tt.want)
+		}
+	}
+}
+
+func TestLevelLabels(t *testing.T) {
+	const levelNotice = slog.Level(2)
+
+	var buf bytes.Buffer
+	l := slog.New(slogcolor.NewHandler(&buf, &slogcolor.Options{
+		Level:       slog.LevelDebug,
+		NoTime:      true,
+		ForceColor:  true,
+		LevelLabels: map[slog.Level]string{levelNotice: "NOTICE", slog.LevelWarn: "W"},
+		LevelWidth:  4,
+		Theme: &slogcolor.Theme{Levels: map[slog.Level]*color.Color{
+			slog.LevelInfo: color.New(color.FgGreen),
+			slog.LevelWarn: color.New(color.FgYellow),
+		}},
+	}))
+	ctx := context.Background()
+	l.Info("info")
+	l.Log(ctx, levelNotice, "notice")
+	l.Warn("warn")
+	l.Debug("debug")
+	l.Log(ctx, slog.Level(6), "custom")
+
+	want := "\x1b[32mINFO\x1b[0m info\n" +
+		"\x1b[32mNOTI\x1b[0m notice\n" +
+		"\x1b[33mW   \x1b[0m warn\n" +
+		"\x1b[32mDEBU\x1b[0m debug\n" +
+		"\x1b[33mWARN\x1b[0m custom\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestLevelWidthWideLabels(t *testing.T) {
+	for _, tt := range []struct {
+		label string
+		width int
+		want  string
+	}{
+		{"情報情報", 5, "情報 "},
+		{"情報情報", 4, "情報"},
+		{"情報", 5, "情報 "},
+		{"🔥🔥🔥", 5, "🔥🔥 "},
+		{"⚠️ WARN", 3, "⚠️ "},
+		{"ÄÖÜ-INFO", 5, "ÄÖÜ-I"},
+	} {
+		var buf bytes.Buffer
+		slog.New(slogcolor.NewHandler(&buf, &slogcolor.Options{
+			Level:       slog.LevelInfo,
+			NoColor:     true,
+			NoTime:      true,
+			LevelLabels: map[slog.Level]string{slog.LevelInfo: tt.label},
+			LevelWidth:  tt.width,
+		})).Info("msg")
+		if got, want := buf.String(), tt.want+" msg\n"; got != want {
+			t.Errorf("label %q with LevelWidth %d: got %q, want %q", tt.label, tt.width, got, want)
+		}
+	}
+}
+
+func TestReplaceAttrBuiltin(t *testing.T) {
+	var buf bytes.Buffer
+	h := slogcolor.NewHandler(&buf, &slogcolor.Options{
+		Level:       slog.LevelInfo,
+		NoColor:     true,
+		TimeFormat:  time.DateTime,
+		SrcFileMode: slogcolor.ShortFile,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if len(groups) > 0 {
+				return a
+			}
+			switch a.Key {
+			case slog.TimeKey:
+				return slog.Time(a.Key, a.Value.Time().UTC())
+			case slog.LevelKey:
+				if a.Value.Any().(slog.Level) == slog.LevelWarn {
+					return slog.String(a.Key, "WARNING")
+				}
+				return slog.Any(a.Key, slog.LevelError)
+			case slog.SourceKey:
+				if a.Value.Any().(*slog.Source).Line == 0 {
+					t.Error("expected a resolved source")
+				}
+				return slog.String(a.Key, "src")
+			case slog.MessageKey:
+				return slog.String(a.Key, strings.ToUpper(a.Value.String()))
+			}
+			return a
+		},
+	})
+
+	r := slog.NewRecord(time.Date(2024, 1, 2, 3, 4, 5, 0, time.FixedZone("X", 3600)), slog.LevelInfo, "hello", callerPC())
+	h.Handle(context.Background(), r)
+	r.Level = slog.LevelWarn
+	r.Time = time.Time{}
+	h.Handle(context.Background(), r)
+
+	want := "2024-01-02 02:04:05 ERROR src HELLO\nWARNING src HELLO\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	buf.Reset()
+	h = slogcolor.NewHandler(&buf, &slogcolor.Options{
+		Level:       slog.LevelInfo,
+		NoColor:     true,
+		SrcFileMode: slogcolor.ShortFile,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if len(groups) == 0 && a.Key != "k" {
+				return slog.Attr{}
+			}
+			return a
+		},
+	})
+	slog.New(h).Info("msg", "k", "v")
+	if want := "k=v\n"; buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
+
+func callerPC() uintptr {
+	var pcs [1]uintptr
+	runtime.Callers(2, pcs[:])
+	return pcs[0]
+}
+
+type stackError struct{ pcs []uintptr }
+
+func (e stackError) Error() string         { return "boom" }
+func (e stackError) StackTrace() []uintptr { return e.pcs }
+
+// stackHere returns a synthetic stack of stackHere and its caller.
+func stackHere() []uintptr {
+	pcs := make([]uintptr, 2)
+	return pcs[:runtime.Callers(1, pcs)]
+}
+
+func TestStackTrace(t *testing.T) {
+	pcs := stackHere()
+	frames := `\n    github.com/geomyidia/slogcolor_test\.stackHere .*/handler_test\.go:\d+` +
+		`\n    github.com/geomyidia/slogcolor_test\.TestStackTrace .*/handler_test\.go:\d+`
+	opts := &slogcolor.Options{Level: slog.LevelInfo, NoColor: true, NoTime: true, StackTraceKey: "stack"}
+	for _, tt := range []struct {
+		opts *slogcolor.Options
+		args []any
+		want string
+	}{
+		{opts, []any{"stack", pcs, "k", "v"}, `^INFO  msg k=v\n  stack:` + frames + `\n$`},
+		{opts, []any{"stack", stackError{pcs}}, `^INFO  msg stack=boom\n  stack:` + frames + `\n$`},
+		{opts, []any{"trace", pcs}, `^INFO  msg trace=\[\d+ \d+\]\n$`},
+		{
+			&slogcolor.Options{Level: slog.LevelInfo, NoColor: true, NoTime: true, StackTraceExtractor: func(a slog.Attr) []uintptr {
+				if err, ok := a.Value.Any().(stackError); ok {
+					return err.pcs
+				}
+				return nil
+			}},
+			[]any{slog.Group("req", "err", stackError{pcs})},
+			`^INFO  msg req.err=boom\n  req.err:` + frames + `\n$`,
+		},
+	} {
+		var buf bytes.Buffer
+		slog.New(slogcolor.NewHandler(&buf, tt.opts)).Info("msg", tt.args...)
+		if got := buf.String(); !regexp.MustCompile(tt.want).MatchString(got) {
+			t.Errorf("got %q, want match of %q", got, tt.want)
+		}
+	}
+}
+
+func TestGroupStyle(t *testing.T) {
+	for _, tt := range []struct {
+		style slogcolor.GroupStyle
+		want  string
+	}{
+		{slogcolor.GroupFlat, "INFO  msg app=srv http.method=GET http.req.status=200 http.req.client.ip=::1 http.id=1\n"},
+		{slogcolor.GroupBraces, "INFO  msg app=srv http={ method=GET req={ status=200 client={ ip=::1 } } id=1 }\n"},
+		{slogcolor.GroupIndent, "INFO  msg app=srv\n" +
+			"  http:\n" +
+			"    method=GET\n" +
+			"    req:\n" +
+			"      status=200\n" +
+			"      client:\n" +
+			"        ip=::1\n" +
+			"    id=1\n"},
+	} {
+		var buf bytes.Buffer
+		h := slogcolor.NewHandler(&buf, &slogcolor.Options{Level: slog.LevelInfo, NoColor: true, NoTime: true, GroupStyle: tt.style})
+		l := slog.New(h).With("app", "srv").WithGroup("http").With("method", "GET")
+		l.Info("msg", slog.Group("req", "status", 200, slog.Group("client", "ip", "::1")), "id", 1)
+		if got := buf.String(); got != tt.want {
+			t.Errorf("style %d: got %q, want %q", tt.style, got, tt.want)
+		}
+	}
+}
+
+func TestGroupBracesColor(t *testing.T) {
+	var buf bytes.Buffer
+	h := slogcolor.NewHandler(&buf, &slogcolor.Options{
+		Level:      slog.LevelInfo,
+		NoTime:     true,
+		ForceColor: true,
+		GroupStyle: slogcolor.GroupBraces,
+		Theme:      &slogcolor.Theme{Key: color.New(color.FgCyan), Group: color.New(color.FgBlue), Number: color.New(color.FgYellow)},
+	})
+	slog.New(h).Info("msg", slog.Group("addr", "host", "::1", slog.Group("port", "tcp", 8080)))
+	want := "INFO  msg \x1b[34maddr\x1b[0m={ \x1b[36mhost=\x1b[0m::1 \x1b[34mport\x1b[0m={ \x1b[36mtcp=\x1b[0m\x1b[33m8080\x1b[0m } }\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestGroupStyleEmptyGroups(t *testing.T) {
+	for _, tt := range []struct {
+		style slogcolor.GroupStyle
+		want  string
+	}{
+		{slogcolor.GroupFlat, "INFO  msg a.b.k=v\n"},
+		{slogcolor.GroupBraces, "INFO  msg a={ b={ k=v } }\n"},
+		{slogcolor.GroupIndent, "INFO  msg\n  a:\n    b:\n      k=v\n"},
+	} {
+		var buf bytes.Buffer
+		h := slogcolor.NewHandler(&buf, &slogcolor.Options{Level: slog.LevelInfo, NoColor: true, NoTime: true, GroupStyle: tt.style})
+		slog.New(h).Info("msg", slog.Group("empty"), slog.Group("a", slog.Group("none"), slog.Group("b", "k", "v")))
+		if got := buf.String(); got != tt.want {
+			t.Errorf("style %d: got %q, want %q", tt.style, got, tt.want)
+		}
+	}
+
+	var buf bytes.Buffer
+	h := slogcolor.NewHandler(&buf, &slogcolor.Options{
+		Level:      slog.LevelInfo,
+		NoTime:     true,
+		ForceColor: true,
+		GroupStyle: slogcolor.GroupIndent,
+		Theme:      &slogcolor.Theme{Key: color.New(color.FgCyan), Group: color.New(color.FgBlue), String: color.New(color.FgGreen)},
+	})
+	slog.New(h).Info("msg", slog.Group("a", slog.Group("b", "k", "v")))
+	want := "INFO  msg\n  \x1b[34ma:\x1b[0m\n    \x1b[34mb:\x1b[0m\n      \x1b[36mk=\x1b[0m\x1b[32mv\x1b[0m\n"
+	if got := buf.String(); got != want {
+		t.Errorf("colored: got %q, want %q", got, want)
+	}
+}
+
+func TestFieldSeparator(t *testing.T) {
+	for _, tt := range []struct {
+		opts slogcolor.Options
+		want string
+	}{
+		{slogcolor.Options{FieldSeparator: ", "}, "INFO  msg a=1, long=2, k.b=3\n"},
+		{slogcolor.Options{AlignKeys: true}, "INFO  msg a   =1 long=2 k.b =3\n"},
+		{slogcolor.Options{AlignKeys: true, GroupStyle: slogcolor.GroupIndent}, "INFO  msg a   =1 long=2\n  k:\n    b   =3\n"},
+		{slogcolor.Options{FieldSeparator: ",", GroupStyle: slogcolor.GroupBraces}, "INFO  msg a=1,long=2,k={ b=3 }\n"},
+	} {
+		var buf bytes.Buffer
+		opts := tt.opts
+		opts.Level = slog.LevelInfo
+		opts.NoColor = true
+		opts.NoTime = true
+		slog.New(slogcolor.NewHandler(&buf, &opts)).Info("msg", "a", 1, "long", 2, slog.Group("k", "b", 3))
+		if got := buf.String(); got != tt.want {
+			t.Errorf("got %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestSrcHyperlink(t *testing.T) {
+	_, file, _, _ := runtime.Caller(0)
+	link := "\x1b]8;;file://" + file + "#"
+
+	for _, tt := range []struct {
+		name     string
+		opts     slogcolor.Options
+		wantLink bool
+	}{
+		{"disabled", slogcolor.Options{ForceColor: true, SrcFileMode: slogcolor.LongFile}, false},
+		{"LongFile", slogcolor.Options{ForceColor: true, SrcFileMode: slogcolor.LongFile, SrcHyperlink: true}, true},
+		{"MediumFile", slogcolor.Options{ForceColor: true, SrcFileMode: slogcolor.MediumFile, SrcHyperlink: true}, true},
+		{"PackageFile", slogcolor.Options{ForceColor: true, SrcFileMode: slogcolor.PackageFile, SrcHyperlink: true}, true},
+		{"ShortFile", slogcolor.Options{ForceColor: true, SrcFileMode: slogcolor.ShortFile, SrcHyperlink: true}, true},
+		{"Nop", slogcolor.Options{ForceColor: true, SrcFileMode: slogcolor.Nop, SrcHyperlink: true}, false},
+		{"NoColor", slogcolor.Options{NoColor: true, SrcFileMode: slogcolor.LongFile, SrcHyperlink: true}, false},
+	} {
+		var buf bytes.Buffer
+		opts := tt.opts
+		opts.NoTime = true
+		slog.New(slogcolor.NewHandler(&buf, &opts)).Info("msg")
+		if got := strings.Contains(buf.String(), link); got != tt.wantLink {
+			t.Errorf("%s: got link %v, want %v (%q)", tt.name, got, tt.wantLink, buf.String())
+		}
+		if tt.wantLink && !strings.Contains(buf.String(), "\x1b]8;;\x1b\\ ") {
+			t.Errorf("%s: link not closed before the message (%q)", tt.name, buf.String())
+		}
+		if tt.name == "ShortFile" && !regexp.MustCompile(`#\d+\x1b\\handler_test\.go:\d+\x1b\]8;;`).MatchString(buf.String()) {
+			t.Errorf("%s: link text is not the short file name (%q)", tt.name, buf.String())
+		}
+	}
+}
+
+func TestSrcFormatter(t *testing.T) {
+	var buf bytes.Buffer
+	slog.New(slogcolor.NewHandler(&buf, &slogcolor.Options{
+		Level:       slog.LevelInfo,
+		NoColor:     true,
+		NoTime:      true,
+		SrcFileMode: slogcolor.Nop,
+		SrcFormatter: func(src *slog.Source) string {
+			return fmt.Sprintf("%s:%d", src.Function, src.Line)
+		},
+	})).Info("msg")
+
+	want := "INFO  github.com/geomyidia/slogcolor_test.TestSrcFormatter:"
+	if got := buf.String(); !strings.HasPrefix(got, want) || !strings.HasSuffix(got, " msg\n") {
+		t.Errorf("got %q, want %q...", got, want)
+	}
+}
+
+func logFromNamedFunction(l *slog.Logger) { l.Info("msg") }
+
+func TestSrcShowFunction(t *testing.T) {
+	var buf bytes.Buffer
+	logFromNamedFunction(slog.New(slogcolor.NewHandler(&buf, &slogcolor.Options{
+		Level:           slog.LevelInfo,
+		NoColor:         true,
+		NoTime:          true,
+		SrcFileMode:     slogcolor.ShortFile,
+		SrcShowFunction: true,
+	})))
+
+	re := regexp.MustCompile(`^INFO  handler_test\.go:\d+ \(slogcolor_test\.logFromNamedFunction\) msg\n$`)
+	if got := buf.String(); !re.MatchString(got) {
+		t.Errorf("got %q, want %s", got, re)
+	}
+}
+
+func logFromFunktionÄÖÜ(l *slog.Logger) { l.Info("msg") }
+
+func TestSrcShowFunctionLength(t *testing.T) {
+	// the source with the function is about 45 characters, shorter lengths truncate the file and then the function
+	for width := 1; width <= 60; width++ {
+		for _, format := range []slogcolor.Format{slogcolor.FormatColor, slogcolor.FormatLogfmt} {
+			var buf bytes.Buffer
+			logFromFunktionÄÖÜ(slog.New(slogcolor.NewHandler(&buf, &slogcolor.Options{
+				Level:           slog.LevelInfo,
+				NoColor:         true,
+				NoTime:          true,
+				Format:          format,
+				SrcFileMode:     slogcolor.ShortFile,
+				SrcShowFunction: true,
+				SrcFileLength:   width,
+			})))
+			got := buf.String()
+			if format == slogcolor.FormatLogfmt {
+				if !strings.Contains(got, "msg=msg") {
+					t.Errorf("logfmt SrcFileLength %d: got %q", width, got)
+				}
+				continue
+			}
+			src, ok := strings.CutPrefix(strings.TrimSuffix(got, "msg\n"), "INFO  ")
+			if !ok || utf8.RuneCountInString(src) != width || !strings.HasSuffix(src, " ") {
+				t.Errorf("SrcFileLength %d: got %q, want the source padded to %d characters", width, got, width)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	logFromFunktionÄÖÜ(slog.New(slogcolor.NewHandler(&buf, &slogcolor.Options{
+		Level:           slog.LevelInfo,
+		NoColor:         true,
+		NoTime:          true,
+		SrcFileMode:     slogcolor.ShortFile,
+		SrcShowFunction: true,
+		SrcFileLength:   60,
+	})))
+	re := regexp.MustCompile(`^INFO  handler_test\.go:\d+ \(slogcolor_test\.logFromFunktionÄÖÜ\) +msg\n$`)
+	if got := buf.String(); !re.MatchString(got) {
+		t.Errorf("got %q, want %s", got, re)
+	}
+}
+
+func TestPadLevelText(t *testing.T) {
+	const (
+		levelTrace  = slog.Level(-8)
+		levelNotice = slog.Level(2)
+	)
+	standard := []slog.Level{slog.LevelDebug, slog.LevelInfo, slog.LevelWarn, slog.LevelError}
+
+	for _, tt := range []struct {
+		labels map[slog.Level]string
+		levels []slog.Level
+		want   string
+	}{
+		{nil, standard, "DEBUG m\nINFO  m\nWARN  m\nERROR m\n"},
+		{
+			map[slog.Level]string{slog.LevelDebug: "DBG", slog.LevelInfo: "INF", slog.LevelWarn: "WRN", slog.LevelError: "ERR"},
+			standard,
+			"DBG m\nINF m\nWRN m\nERR m\n",
+		},
+		{
+			map[slog.Level]string{levelTrace: "TRACE", levelNotice: "NOTICE"},
+			[]slog.Level{levelTrace, slog.LevelDebug, slog.LevelInfo, levelNotice, slog.LevelWarn},
+			"TRACE  m\nDEBUG  m\nINFO   m\nNOTICE m\nWARN   m\n",
+		},
+	} {
+		var buf bytes.Buffer
+		l := slog.New(slogcolor.NewHandler(&buf, &slogcolor.Options{
+			Level:        levelTrace,
+			NoColor:      true,
+			NoTime:       true,
+			LevelLabels:  tt.labels,
+			PadLevelText: true,
+		}))
+		for _, lvl := range tt.levels {
+			l.Log(context.Background(), lvl, "m")
+		}
+		if got := buf.String(); got != tt.want {
+			t.Errorf("got %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestErrorValues(t *testing.T) {
+	inner := errors.New("no such file")
+	err := fmt.Errorf("read config: %w", fmt.Errorf("open app.yaml: %w", inner))
+
+	for _, tt := range []struct {
+		unwrap bool
+		want   string
+	}{
+		{false, "INFO  msg \x1b[36mcause=\x1b[0m\x1b[31mread config: open app.yaml: no such file\x1b[0m\n"},
+		{true, "INFO  msg \x1b[36mcause=\x1b[0m\x1b[31mread config: open app.yaml: no such file\x1b[0m" +
+			"\n  \x1b[2m→ caused by: \x1b[22m\x1b[31mopen app.yaml: no such file\x1b[0m" +
+			"\n  \x1b[2m→ caused by: \x1b[22m\x1b[31mno such file\x1b[0m\n"},
+	} {
+		var buf bytes.Buffer
+		slog.New(slogcolor.NewHandler(&buf, &slogcolor.Options{
+			Level:        slog.LevelInfo,
+			NoTime:       true,
+			ForceColor:   true,
+			LevelTags:    map[slog.Level]string{slog.LevelInfo: "INFO "},
+			UnwrapErrors: tt.unwrap,
+		})).Info("msg", "cause", err)
+		if got := buf.String(); got != tt.want {
+			t.Errorf("UnwrapErrors %v: got %q, want %q", tt.unwrap, got, tt.want)
+		}
+	}
+}
+
+// cycleError wraps itself, like a broken Unwrap implementation.
+type cycleError struct{}
+
+func (e *cycleError) Error() string { return "cycle" }
+func (e *cycleError) Unwrap() error { return e }
+
+// nilError is an error whose methods panic on a nil pointer.
+type nilError struct{ msg string }
+
+func (e *nilError) Error() string { return e.msg }
+
+func TestUnwrapErrors(t *testing.T) {
+	pcs := make([]uintptr, 10)
+	pcs = pcs[:runtime.Callers(0, pcs)]
+	for _, tt := range []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"not wrapped", io.EOF, `^INFO  msg err=EOF\n$`},
+		{"typed nil", (*nilError)(nil), `^INFO  msg err=<nil>\n$`},
+		{"wrapped nil", fmt.Errorf("load: %w", (*nilError)(nil)), `^INFO  msg err=load: <nil>\n  → caused by: <nil>\n$`},
+		{"cycle", &cycleError{}, `^INFO  msg err=cycle(\n  → caused by: cycle){10}\n$`},
+		{"stack", fmt.Errorf("load: %w", stackError{pcs}),
+			`^INFO  msg err=load: boom\n  → caused by: boom` +
+				`\n    runtime\.Callers .*\n    github.com/geomyidia/slogcolor_test\.TestUnwrapErrors .*/handler_test\.go:\d+\n    testing\.tRunner .*\n$`},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			slog.New(slogcolor.NewHandler(&buf, &slogcolor.Options{
+				Level:        slog.LevelInfo,
+				NoColor:      true,
+				NoTime:       true,
+				UnwrapErrors: true,
+			})).Info("msg", "err", tt.err)
+			if got := buf.String(); !regexp.MustCompile(tt.want).MatchString(got) {
+				t.Errorf("got %q, want match of %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCustomLevels(t *testing.T) {
+	const (
+		levelTrace = slog.Level(-8)
+		levelFatal = slog.Level(12)
+	)
+
+	var buf bytes.Buffer
+	l := slog.New(slogcolor.NewHandler(&buf, &slogcolor.Options{
+		Level:      levelTrace,
+		NoTime:     true,
+		ForceColor: true,
+		CustomLevels: []slogcolor.LevelDef{
+			{Level: levelFatal, Name: "FATAL", Color: color.New(color.FgHiRed)},
+			{Level: levelTrace, Name: "TRACE", Color: color.New(color.FgBlue)},
+		},
+	}))
+	l.Log(context.Background(), levelTrace, "trace")
+	l.Log(context.Background(), levelFatal, "fatal")
+
+	want := "\x1b[34mTRACE\x1b[0m trace\n\x1b[91mFATAL\x1b[0m fatal\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestMaxLineWidth(t *testing.T) {
+	for _, tt := range []struct {
+		width    int
+		ellipsis string
+		want     string
+	}{
+		{0, "", `^INFO  handler_test\.go:\d+ msg key=value\n$`},
+		{100, "", `^INFO  handler_test\.go:\d+ msg key=value\n$`},
+		{19, "", `^INFO  msg key=value\n$`},
+		{12, "", `^INFO  msg k…\n$`},
+		{12, "...", `^INFO  msg\.\.\.\n$`},
+		{4, "", `^INF…\n$`},
+	} {
+		var buf bytes.Buffer
+		slog.New(slogcolor.NewHandler(&buf, &slogcolor.Options{
+			Level:         slog.LevelInfo,
+			NoColor:       true,
+			NoTime:        true,
+			SrcFileMode:   slogcolor.ShortFile,
+			MaxLineWidth:  tt.width,
+			EllipsisStyle: tt.ellipsis,
+		})).Info("msg", "key", "value")
+		if got := buf.String(); !regexp.MustCompile(tt.want).MatchString(got) {
+			t.Errorf("MaxLineWidth %d: got %q, want match of %q", tt.width, got, tt.want)
+		}
+	}
+
+	var buf bytes.Buffer
+	slog.New(slogcolor.NewHandler(&buf, &slogcolor.Options{
+		Level:        slog.LevelInfo,
+		NoTime:       true,
+		ForceColor:   true,
+		MaxLineWidth: 12,
+	})).Info("msg", "key", "value")
+	if want := "\x1b[42;97mINFO \x1b[0;0m msg \x1b[36mk\x1b[0m…\n"; buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
+
+func TestLineTerminator(t *testing.T) {
+	for _, tt := range []struct {
+		opts slogcolor.Options
+		want string
+	}{
+		{slogcolor.Options{}, "INFO  one k=v\nINFO  two\n"},
+		{slogcolor.Options{LineTerminator: "\r\n"}, "INFO  one k=v\r\nINFO  two\r\n"},
+		{slogcolor.Options{NoLineTerminator: true}, "INFO  one k=vINFO  two"},
+		{slogcolor.Options{Format: slogcolor.FormatLogfmt, LineTerminator: "\r\n"}, "level=INFO msg=one k=v\r\nlevel=INFO msg=two\r\n"},
+	} {
+		var buf recordWriter
+		opts := tt.opts
+		opts.Level = slog.LevelInfo
+		opts.NoColor = true
+		opts.NoTime = true
+		opts.EscapeNewlines = true
+		l := slog.New(slogcolor.NewHandler(&buf, &opts))
+		l.Info("one", "k", "v")
+		l.Info("two")
+		if got := strings.Join(buf.writes, ""); got != tt.want {
+			t.Errorf("got %q, want %q", got, tt.want)
+		}
+		if len(buf.writes) != 2 {
+			t.Errorf("got %d writes, want one per record", len(buf.writes))
+		}
+	}
+}
+
+func TestAttrIndent(t *testing.T) {
+	for _, tt := range []struct {
+		opts slogcolor.Options
+		want string
+	}{
+		{slogcolor.Options{}, "INFO  msg a=1 b=2\n"},
+		{slogcolor.Options{AttrIndent: "    "}, "INFO  msg    a=1 b=2\n"},
+		{slogcolor.Options{AttrIndent: " | ", FieldSeparator: ", "}, "INFO  msg | a=1, b=2\n"},
+		{slogcolor.Options{AttrIndent: "  ", MsgLength: 6}, "INFO  msg     a=1 b=2\n"},
+		{slogcolor.Options{AttrIndent: "  ", OmitFields: slogcolor.OmitMessage}, "INFO  a=1 b=2\n"},
+	} {
+		var buf bytes.Buffer
+		opts := tt.opts
+		opts.Level = slog.LevelInfo
+		opts.NoColor = true
+		opts.NoTime = true
+		slog.New(slogcolor.NewHandler(&buf, &opts)).Info("msg", "a", 1, "b", 2)
+		if got := buf.String(); got != tt.want {
+			t.Errorf("AttrIndent %q: got %q, want %q", tt.opts.AttrIndent, got, tt.want)
+		}
+	}
+}
+
+func TestSrcPosition(t *testing.T) {
+	for _, tt := range []struct {
+		position slogcolor.SrcPosition
+		opts     slogcolor.Options
+		args     []any
+		want     string
+	}{
+		{slogcolor.SrcPositionAfterLevel, slogcolor.Options{}, []any{"k", "v"}, `^INFO  handler_test\.go:\d+ msg k=v\n$`},
+		{slogcolor.SrcPositionAfterAttrs, slogcolor.Options{}, []any{"k", "v"}, `^INFO  msg k=v handler_test\.go:\d+\n$`},
+		{slogcolor.SrcPositionAfterAttrs, slogcolor.Options{}, nil, `^INFO  msg handler_test\.go:\d+\n$`},
+		{slogcolor.SrcPositionAfterAttrs, slogcolor.Options{SrcFuncMode: slogcolor.FuncShortName}, nil,
+			`^INFO  msg TestSrcPosition handler_test\.go:\d+\n$`},
+		{slogcolor.SrcPositionAfterAttrs, slogcolor.Options{OmitFields: slogcolor.OmitSource}, []any{"k", "v"}, `^INFO  msg k=v\n$`},
+		{slogcolor.SrcPositionAfterAttrs, slogcolor.Options{MaxLineWidth: 15}, []any{"k", "v"}, `^INFO  msg k=v\n$`},
+		{slogcolor.SrcPositionAfterAttrs, slogcolor.Options{ForceColor: true, Theme: &slogcolor.Theme{}, SrcFileColor: color.New(color.Faint)},
+			[]any{"k", "v"}, `^INFO  msg k=v \x1b\[2mhandler_test\.go:\d+\x1b\[22m\n$`},
+	} {
+		var buf bytes.Buffer
+		opts := tt.opts
+		opts.Level = slog.LevelInfo
+		opts.NoColor = !opts.ForceColor
+		opts.NoTime = true
+		opts.SrcFileMode = slogcolor.ShortFile
+		opts.SrcPosition = tt.position
+		slog.New(slogcolor.NewHandler(&buf, &opts)).Info("msg", tt.args...)
+		if got := buf.String(); !regexp.MustCompile(tt.want).MatchString(got) {
+			t.Errorf("SrcPosition %d: got %q, want match of %q", tt.position, got, tt.want)
+		}
+	}
+}
+
+func TestColumnSeparator(t *testing.T) {
+	tm := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+	for _, tt := range []struct {
+		name string
+		opts slogcolor.Options
+		want string
+	}{
+		{"pipe", slogcolor.Options{ColumnSeparator: "|"}, `^2024-01-02 15:04:05\|INFO \|handler_test\.go:\d+\|msg k=v\n$`},
+		{"source after attrs", slogcolor.Options{ColumnSeparator: " | ", SrcPosition: slogcolor.SrcPositionAfterAttrs},
+			`^2024-01-02 15:04:05 \| INFO  \| msg k=v \| handler_test\.go:\d+\n$`},
+		{"padded source", slogcolor.Options{ColumnSeparator: "|", SrcFileLength: 24}, `^2024-01-02 15:04:05\|INFO \|handler_test\.go:\d+ +\|msg k=v\n$`},
+		{"color", slogcolor.Options{ColumnSeparator: "|", ForceColor: true, Theme: &slogcolor.Theme{}, SeparatorColor: color.New(color.Faint)},
+			`^2024-01-02 15:04:05\x1b\[2m\|\x1b\[22mINFO \x1b\[2m\|\x1b\[22mhandler_test\.go:\d+\x1b\[2m\|\x1b\[22mmsg k=v\n$`},
+	} {
+		var buf bytes.Buffer
+		opts := tt.opts
+		opts.Level = slog.LevelInfo
+		opts.NoColor = !opts.ForceColor
+		opts.SrcFileMode = slogcolor.ShortFile
+		opts.MsgPrefix = ""
+		r := slog.NewRecord(tm, slog.LevelInfo, "msg", callerPC())
+		r.AddAttrs(slog.String("k", "v"))
+		slogcolor.NewHandler(&buf, &opts).Handle(context.Background(), r)
+		if got := buf.String(); !regexp.MustCompile(tt.want).MatchString(got) {
+			t.Errorf("%s: got %q, want match of %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestLayout(t *testing.T) {
+	for _, tt := range []struct {
+		layout slogcolor.Layout
+		opts   slogcolor.Options
+		want   string
+	}{
+		{slogcolor.LayoutCompact, slogcolor.Options{}, "INFO  request method=GET http.status=200\n"},
+		{slogcolor.LayoutExpanded, slogcolor.Options{}, "INFO  request\n  method: GET\n  http.status: 200\n"},
+		{slogcolor.LayoutExpanded, slogcolor.Options{AlignKeys: true}, "INFO  request\n  method:      GET\n  http.status: 200\n"},
+		{slogcolor.LayoutExpanded, slogcolor.Options{GroupStyle: slogcolor.GroupBraces}, "INFO  request\n  method: GET\n  http.status: 200\n"},
+		{slogcolor.LayoutExpanded, slogcolor.Options{ForceColor: true, Theme: &slogcolor.Theme{}, KeyColor: color.New(color.FgCyan),
+			NumberColor: color.New(color.FgBlue)},
+			"INFO  request\n  \x1b[36mmethod:\x1b[0m GET\n  \x1b[36mhttp.status:\x1b[0m \x1b[34m200\x1b[0m\n"},
+	} {
+		var buf bytes.Buffer
+		opts := tt.opts
+		opts.Level = slog.LevelInfo
+		opts.NoColor = !opts.ForceColor
+		opts.NoTime = true
+		opts.Layout = tt.layout
+		slog.New(slogcolor.NewHandler(&buf, &opts)).Info("request", "method", "GET", slog.Group("http", "status", 200))
+		if got := buf.String(); got != tt.want {
+			t.Errorf("Layout %d %+v: got %q, want %q", tt.layout, tt.opts, got, tt.want)
+		}
+	}
+}
+
+func TestMultiline(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		opts slogcolor.Options
+		want string
+	}{
+		{"default", slogcolor.Options{}, "INFO  request\n" +
+			"    method      = GET\n" +
+			"    path        = /api/users\n" +
+			"    http:\n" +
+			"        status = 200\n" +
+			"        req:\n" +
+			"            id = 7\n" +
+			"        ok     = true\n" +
+			"    duration_ms = 750\n"},
+		{"indent and separator", slogcolor.Options{MultilineIndent: "\t", MultilineSeparator: "─────"}, "INFO  request\n" +
+			"\tmethod      = GET\n" +
+			"\tpath        = /api/users\n" +
+			"\thttp:\n" +
+			"\t\tstatus = 200\n" +
+			"\t\treq:\n" +
+			"\t\t\tid = 7\n" +
+			"\t\tok     = true\n" +
+			"\tduration_ms = 750\n" +
+			"─────\n"},
+	} {
+		var buf bytes.Buffer
+		opts := tt.opts
+		opts.Level = slog.LevelInfo
+		opts.NoColor = true
+		opts.NoTime = true
+		opts.Multiline = true
+		slog.New(slogcolor.NewHandler(&buf, &opts)).Info("request", "method", "GET", "path", "/api/users",
+			slog.Group("http", "status", 200, slog.Group("req", "id", 7), "ok", true), "duration_ms", 750)
+		if got := buf.String(); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestWidth(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		opts  slogcolor.Options
+		width int
+		want  string
+	}{
+		{"no wrap", slogcolor.Options{}, 100, "INFO  request method=GET path=/api/users status=200\n"},
+		{"disabled", slogcolor.Options{}, -1, "INFO  request method=GET path=/api/users status=200\n"},
+		{"wrap", slogcolor.Options{}, 30, "INFO  request method=GET\n" +
+			"      path=/api/users\n" +
+			"      status=200\n"},
+		{"two per line", slogcolor.Options{}, 40, "INFO  request method=GET path=/api/users\n" +
+			"      status=200\n"},
+		{"message prefix", slogcolor.Options{MsgPrefix: "| "}, 40, "INFO  | request method=GET\n" +
+			"        path=/api/users status=200\n"},
+		{"long message", slogcolor.Options{MsgPrefix: "| "}, 10, "INFO  | request\n" +
+			"        method=GET\n" +
+			"        path=/api/users\n" +
+			"        status=200\n"},
+		{"max line width", slogcolor.Options{MaxLineWidth: 30}, 30, "INFO  request method=GET path…\n"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			opts := tt.opts
+			opts.Level = slog.LevelInfo
+			opts.NoColor = true
+			opts.NoTime = true
+			opts.Width = tt.width
+			slog.New(slogcolor.NewHandler(&buf, &opts)).Info("request", "method", "GET", "path", "/api/users", "status", 200)
+			if got := buf.String(); got != tt.want {
+				t.Errorf("got\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+
+	var buf bytes.Buffer
+	slog.New(slogcolor.NewHandler(&buf, &slogcolor.Options{
+		Level:      slog.LevelInfo,
+		ForceColor: true,
+		NoTime:     true,
+		Width:      30,
+	})).Info("request", "method", "GET", "path", "/api/users")
+	lines := strings.Split(regexp.MustCompile(`\x1b\[[0-9;]*m`).ReplaceAllString(buf.String(), ""), "\n")
+	if len(lines) != 3 || lines[1] != "      path=/api/users" {
+		t.Errorf("colored: got %q, want the path on a continuation line under the message", lines)
+	}
+}
+
+func TestSortAttrs(t *testing.T) {
+	var buf bytes.Buffer
+	l := slog.New(slogcolor.NewHandler(&buf, &slogcolor.Options{Level: slog.LevelInfo, NoColor: true, NoTime: true, SortAttrs: true}))
+	l.With("m", 1, "b", 2).Info("msg", "z", 3, slog.Group("c", "y", 4, "x", 5, slog.Group("a", "k", 6)), "a", 7, "c", 8)
+
+	want := "INFO  msg a=7 b=2 c=8 c.a.k=6 c.x=5 c.y=4 m=1 z=3\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestLevelIcons(t *testing.T) {
+	icons := map[slog.Level]string{
+		slog.LevelDebug: "🐛",
+		slog.LevelInfo:  "ℹ️",
+		slog.LevelWarn:  "⚠️",
+		slog.LevelError: "🔥",
+	}
+	for _, tt := range []struct {
+		icons map[slog.Level]string
+		mode  slogcolor.IconMode
+		want  string
+	}{
+		{nil, slogcolor.IconPrepend, "DEBUG msg\nINFO  msg\nWARN  msg\nERROR msg\n"},
+		{icons, slogcolor.IconReplace, "🐛 msg\nℹ️ msg\n⚠️ msg\n🔥 msg\n"},
+		{icons, slogcolor.IconPrepend, "🐛 DEBUG msg\nℹ️ INFO  msg\n⚠️ WARN  msg\n🔥 ERROR msg\n"},
+		{map[slog.Level]string{slog.LevelError: "🔥"}, slogcolor.IconPrepend, "DEBUG    msg\nINFO     msg\nWARN     msg\n🔥 ERROR msg\n"},
+	} {
+		var buf bytes.Buffer
+		l := slog.New(slogcolor.NewHandler(&buf, &slogcolor.Options{
+			Level:      slog.LevelDebug,
+			NoColor:    true,
+			NoTime:     true,
+			LevelIcons: tt.icons,
+			IconMode:   tt.mode,
+		}))
+		l.Debug("msg")
+		l.Info("msg")
+		l.Warn("msg")
+		l.Error("msg")
+		if got := buf.String(); got != tt.want {
+			t.Errorf("got %q, want %q", got, tt.want)
+		}
+	}
+}
+
+// writeRecorder records every write separately. It is not safe for concurrent use on purpose,
+// so that the race detector reports writes which are not serialized by the handler.
+type writeRecorder struct{ writes []string }
+
+func (w *writeRecorder) Write(p []byte) (int, error) {
+	w.writes = append(w.writes, string(p))
+	return len(p), nil
+}
+
+func TestConcurrentWrites(t *testing.T) {
+	const goroutines, records = 50, 20
+
+	var w writeRecorder
+	root := slog.New(slogcolor.NewHandler(&w, &slogcolor.Options{Level: slog.LevelInfo, NoColor: true, NoTime: true}))
+
+	var wg sync.WaitGroup
+	for i := range goroutines {
+		// every goroutine logs through its own clone of the handler
+		l := root.With("g", i).WithGroup("req")
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range records {
+				l.Info("concurrent", "j", j, "payload", strings.Repeat("x", 100))
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(w.writes) != goroutines*records {
+		t.Fatalf("got %d writes, want %d", len(w.writes), goroutines*records)
+	}
+	re := regexp.MustCompile(`^INFO  concurrent g=\d+ req\.j=\d+ req\.payload=x{100}\n$`)
+	for _, line := range w.writes {
+		if !re.MatchString(line) {
+			t.Fatalf("torn line %q", line)
+		}
+	}
+}
+
+func TestHighlightJSON(t *testing.T) {
+	var buf bytes.Buffer
+	slog.New(slogcolor.NewHandler(&buf, &slogcolor.Options{
+		Level:         slog.LevelInfo,
+		NoTime:        true,
+		ForceColor:    true,
+		HighlightJSON: true,
+	})).Info("msg", "body", `{"id":7}`, "raw", "{not json")
+
+	want := "\x1b[42;97mINFO \x1b[0;0m msg \x1b[36mbody=\x1b[0m{\x1b[36m\"id\"\x1b[0m:\x1b[33m7\x1b[0m} \x1b[36mraw=\x1b[0m{not json\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+// recordWriter records the individual writes.
+type recordWriter struct {
+	mu     sync.Mutex
+	writes []string
+}
+
+func (w *recordWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.writes = append(w.writes, string(p))
+	return len(p), nil
+}
+
+// shortWriter writes only half of every write, without an error.
+type shortWriter struct{}
+
+func (shortWriter) Write(p []byte) (int, error) { return len(p) / 2, nil }
+
+func TestWriteError(t *testing.T) {
+	opts := &slogcolor.Options{Level: slog.LevelInfo, NoColor: true}
+	for _, tt := range []struct {
+		name string
+		h    slog.Handler
+		want error
+	}{
+		{"error", slogcolor.NewHandler(errWriter{}, opts), errWrite},
+		{"short write", slogcolor.NewHandler(shortWriter{}, opts), io.ErrShortWrite},
+		{"tee", slogcolor.NewTeeHandler([]slogcolor.TeeOutput{{Writer: io.Discard}, {Writer: errWriter{}}}, opts), errWrite},
+		{"tee short write", slogcolor.NewTeeHandler([]slogcolor.TeeOutput{{Writer: shortWriter{}}}, opts), io.ErrShortWrite},
+	} {
+		err := tt.h.Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelInfo, "msg", 0))
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%s: got error %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestHandleConcurrent(t *testing.T) {
+	var w recordWriter
+	l := slog.New(slogcolor.NewHandler(&w, &slogcolor.Options{Level: slog.LevelInfo, NoTime: true, ForceColor: true})).With("app", "srv")
+
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := range 100 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			l.Info("concurrent", "i", i, "path", "/api/users")
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	if len(w.writes) != 100 {
+		t.Fatalf("got %d writes, want one per record", len(w.writes))
+	}
+	for _, s := range w.writes {
+		if strings.Count(s, "\n") != 1 || !strings.HasSuffix(s, "\n") || !strings.Contains(s, "concurrent") {
+			t.Errorf("incomplete record %q", s)
+		}
+	}
+}
+
+func TestHandleAllocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("sync.Pool drops items randomly with the race detector")
+	}
+	for _, opts := range []*slogcolor.Options{
+		{Level: slog.LevelInfo, ForceColor: true},
+		{Level: slog.LevelInfo, NoColor: true, SrcFileMode: slogcolor.Nop},
+	} {
+		h := slogcolor.NewHandler(io.Discard, opts)
+		r := slog.NewRecord(time.Now(), slog.LevelInfo, "allocs", 0)
+		r.AddAttrs(slog.Int("i", 42), slog.String("path", "/api/users"), slog.Bool("ok", true))
+		ctx := context.Background()
+
+		// a simple record should not allocate once the pools are warm
+		if allocs := testing.AllocsPerRun(100, func() { h.Handle(ctx, r) }); allocs > 1 {
+			t.Errorf("got %v allocs per record, want at most 1", allocs)
+		}
+	}
+}
+
+func TestDisabledAllocs(t *testing.T) {
+	h := slogcolor.NewHandler(io.Discard, &slogcolor.Options{Level: slog.LevelInfo, ForceColor: true, SrcFileMode: slogcolor.LongFile})
+	l := slog.New(h).With("app", "srv")
+	ctx := context.Background()
+
+	// slog checks Enabled before building the record, so a disabled record must not reach the handler
+	if allocs := testing.AllocsPerRun(100, func() {
+		l.LogAttrs(ctx, slog.LevelDebug, "disabled", slog.Int("i", 42), slog.String("path", "/api/users"), slog.Bool("ok", true))
+	}); allocs != 0 {
+		t.Errorf("got %v allocs per disabled record, want 0", allocs)
+	}
+}
+
+func TestWithPrefix(t *testing.T) {
+	var buf bytes.Buffer
+	h := slogcolor.NewHandler(&buf, &slogcolor.Options{Level: slog.LevelInfo, NoColor: true, NoTime: true})
+	slog.New(h.WithPrefix("[mylib]")).Info("msg")
+	slog.New(h.WithPrefix("[mylib]").WithPrefix("[db]").WithGroup("req").WithAttrs([]slog.Attr{slog.Int("id", 1)})).Info("msg", "k", "v")
+	slog.New(h.WithPrefix("")).Info("msg")
+
+	h = slogcolor.NewHandler(&buf, &slogcolor.Options{Level: slog.LevelInfo, NoColor: true, PrefixSeparator: "/"})
+	slog.New(h.WithPrefix("a").WithPrefix("b")).Info("msg")
+
+	want := regexp.MustCompile(`^\[mylib\] INFO  msg\n` +
+		`\[mylib\] \[db\] INFO  msg req\.id=1 req\.k=v\n` +
+		`INFO  msg\n` +
+		`a/b \d{4}-\d\d-\d\d \d\d:\d\d:\d\d INFO  msg\n$`)
+	if got := buf.String(); !want.MatchString(got) {
+		t.Errorf("got %q, want match of %q", got, want)
+	}
+
+	buf.Reset()
+	h = slogcolor.NewHandler(&buf, &slogcolor.Options{Level: slog.LevelInfo, NoTime: true, ForceColor: true, PrefixColor: color.New(color.FgBlue)})
+	slog.New(h.WithPrefix("[mylib]")).Info("msg")
+	if want := "\x1b[34m[mylib]\x1b[0m \x1b[42;97mINFO "; !strings.HasPrefix(buf.String(), want) {
+		t.Errorf("got %q, want prefix %q", buf.String(), want)
+	}
+}
+
+func TestColorizeLevel(t *testing.T) {
+	for _, tt := range []struct {
+		opts *slogcolor.Options
+		want string
+	}{
+		{nil, "\x1b[42;97mINFO \x1b[0;0m"},
+		{&slogcolor.Options{NoColor: true}, "INFO "},
+		{&slogcolor.Options{LevelColors: map[slog.Level]*color.Color{slog.LevelInfo: color.New(color.FgBlue)}}, "\x1b[34mINFO \x1b[0m"},
+	} {
+		for range 2 { // the output must be stable across calls
+			if got := slogcolor.ColorizeLevel(slog.LevelInfo, tt.opts); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		}
+	}
+}
+
+func TestColorizeAttr(t *testing.T) {
+	for _, tt := range []struct {
+		a    slog.Attr
+		opts *slogcolor.Options
+		want string
+	}{
+		{slog.String("k", "v"), nil, "\x1b[36mk=\x1b[0mv"},
+		{slog.Int("n", 1), &slogcolor.Options{NoColor: true}, "n=1"},
+		{slog.Group("req", "id", 1, "ok", true), &slogcolor.Options{NoColor: true}, "req.id=1 req.ok=true"},
+		{slog.Group("req", "id", 1), &slogcolor.Options{NoColor: true, GroupStyle: slogcolor.GroupBraces}, "req={ id=1 }"},
+		{slog.Attr{}, nil, ""},
+	} {
+		if got := slogcolor.ColorizeAttr(tt.a, tt.opts); got != tt.want {
+			t.Errorf("ColorizeAttr(%v): got %q, want %q", tt.a, got, tt.want)
+		}
+	}
+}
+
+func TestColorizeProfile(t *testing.T) {
+	opts := &slogcolor.Options{Level: slog.LevelInfo, NoTime: true, ColorProfile: slogcolor.ColorProfileANSI16, Theme: slogcolor.ThemeTrueColor}
+	var buf bytes.Buffer
+	slog.New(slogcolor.NewHandler(&buf, &slogcolor.Options{
+		Level:        slog.LevelInfo,
+		ForceColor:   true,
+		NoTime:       true,
+		ColorProfile: slogcolor.ColorProfileANSI16,
+		Theme:        slogcolor.ThemeTrueColor,
+	})).Info("msg", "k", "v")
+
+	level, attr := slogcolor.ColorizeLevel(slog.LevelInfo, opts), slogcolor.ColorizeAttr(slog.String("k", "v"), opts)
+	if strings.Contains(level+attr, "38;2;") || strings.Contains(level+attr, "48;2;") {
+		t.Errorf("got 24-bit colors with ColorProfileANSI16: %q, %q", level, attr)
+	}
+	if want := level + " msg " + attr + "\n"; buf.String() != want {
+		t.Errorf("Handle wrote %q, want %q", buf.String(), want)
+	}
+}
+
+func TestColorizer(t *testing.T) {
+	c := slogcolor.NewColorizer(&slogcolor.Options{NoColor: true})
+	var wg sync.WaitGroup
+	for i := range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if got, want := c.Attr(slog.Int("i", i)), "i="+strconv.Itoa(i); got != want {
+				t.Errorf("got %q, want %q", got, want)
+			}
+			if got := c.Level(slog.LevelWarn); got != "WARN " {
+				t.Errorf("got %q, want %q", got, "WARN ")
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestColorizeGoroutines(t *testing.T) {
+	opts := &slogcolor.Options{
+		BufferSize:  4096,
+		AsyncHook:   true,
+		HookWorkers: 4,
+		AfterHandle: func(slog.Record, error) {},
+	}
+	before := runtime.NumGoroutine()
+	for range 100 {
+		slogcolor.ColorizeLevel(slog.LevelInfo, opts)
+		slogcolor.ColorizeAttr(slog.String("k", "v"), opts)
+	}
+	if n := runtime.NumGoroutine(); n > before+10 {
+		t.Errorf("got %d goroutines after coloring, %d before", n, before)
+	}
+}
+
+func TestShowGoroutineID(t *testing.T) {
+	var buf syncBuffer
+	l := slog.New(slogcolor.NewHandler(&buf, &slogcolor.Options{Level: slog.LevelInfo, NoColor: true, NoTime: true, ShowGoroutineID: true}))
+	l.Info("main")
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		l.Info("other")
+	}()
+	<-done
+
+	m := regexp.MustCompile(`^([0-9a-f]{6}) INFO  main\n([0-9a-f]{6}) INFO  other\n$`).FindStringSubmatch(buf.String())
+	if m == nil {
+		t.Fatalf("unexpected output %q", buf.String())
+	}
+	if m[1] == m[2] || m[1] == "000000" {
+		t.Errorf("got goroutine IDs %s and %s, want distinct nonzero IDs", m[1], m[2])
+	}
+}
+
+func TestMaxAttrValueLen(t *testing.T) {
+	for _, tt := range []struct {
+		max      int
+		ellipsis string
+		value    any
+		want     string
+	}{
+		{0, "", strings.Repeat("x", 100), "k=" + strings.Repeat("x", 100)},
+		{5, "", "short", "k=short"},
+		{5, "", "longer", "k=longe…"},
+		{5, "", "größere", "k=größe…"},
+		{3, "", "日本語です", "k=日本語…"},
+		{4, "", "ab🔥cd", "k=ab🔥c…"},
+		{4, " [...]", "abcdef", "k=abcd [...]"},
+		{3, "", 123456, "k=123…"},
+		{4, "", errors.New("broken"), "k=brok…"},
+	} {
+		var buf bytes.Buffer
+		slog.New(slogcolor.NewHandler(&buf, &slogcolor.Options{
+			Level:           slog.LevelInfo,
+			NoColor:         true,
+			NoTime:          true,
+			MaxAttrValueLen: tt.max,
+			EllipsisStyle:   tt.ellipsis,
+		})).Info("msg", "k", tt.value)
+		if got, want := buf.String(), "INFO  msg "+tt.want+"\n"; got != want {
+			t.Errorf("MaxAttrValueLen %d: got %q, want %q", tt.max, got, want)
+		}
+	}
+
+	var buf bytes.Buffer
+	slog.New(slogcolor.NewHandler(&buf, &slogcolor.Options{
+		Level:           slog.LevelInfo,
+		NoTime:          true,
+		ForceColor:      true,
+		Theme:           &slogcolor.Theme{},
+		StringColor:     color.New(color.FgGreen),
+		MaxAttrValueLen: 2,
+	})).Info("msg", "k", "value")
+	if want := "INFO  msg k=\x1b[32mva\x1b[0m…\n"; buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
+
+func TestMaxMessageLength(t *testing.T) {
+	for _, tt := range []struct {
+		max  int
+		msg  string
+		want string
+	}{
+		{0, strings.Repeat("x", 100), strings.Repeat("x", 100)},
+		{5, "short", "short"},
+		{5, "longer", "longe…"},
+		{5, "größere", "größe…"},
+		{3, "日本語です", "日本語…"},
+		{4, "ab🔥cd", "ab🔥c…"},
+		{4, "a\nbcd", `a\nb…`},
+		{1, "\x1b[31mred\x1b[0m", "r…"},
+		{3, "\x1b[31mred\x1b[0m", "red"},
+	} {
+		var buf bytes.Buffer
+		slog.New(slogcolor.NewHandler(&buf, &slogcolor.Options{
+			Level:            slog.LevelInfo,
+			NoColor:          true,
+			NoTime:           true,
+			EscapeNewlines:   true,
+			MaxMessageLength: tt.max,
+		})).Info(tt.msg, "k", "v")
+		got := buf.String()
+		if !utf8.ValidString(got) {
+			t.Errorf("MaxMessageLength %d: invalid UTF-8 in %q", tt.max, got)
+		}
+		if want := "INFO  " + tt.want + " k=v\n"; got != want {
+			t.Errorf("MaxMessageLength %d: got %q, want %q", tt.max, got, want)
+		}
+	}
+
+	var buf bytes.Buffer
+	slog.New(slogcolor.NewHandler(&buf, &slogcolor.Options{
+		Level:            slog.LevelInfo,
+		NoTime:           true,
+		Format:           slogcolor.FormatLogfmt,
+		MaxMessageLength: 3,
+	})).Info("日本語です", "k", "v")
+	if got, want := buf.String(), "level=INFO msg=日本語… k=v\n"; got != want {
+		t.Errorf("logfmt: got %q, want %q", got, want)
+	}
+
+	buf.Reset()
+	slog.New(slogcolor.NewHandler(&buf, &slogcolor.Options{
+		Level:            slog.LevelInfo,
+		NoTime:           true,
+		ForceColor:       true,
+		Theme:            &slogcolor.Theme{},
+		MaxMessageLength: 1,
+	})).Info("\x1b[31mred\x1b[0m")
+	if got, want := buf.String(), "INFO  \x1b[31mr\x1b[0m…\n"; got != want {
+		t.Errorf("colored message: got %q, want %q", got, want)
+	}
+}
+
+// password is a LogValuer which hides its value.
+type password string
+
+func (password) LogValue() slog.Value { return slog.StringValue("***") }
+
+// user is a LogValuer which resolves to a group containing another LogValuer.
+type user struct {
+	name string
+	pw   password
+}
+
+func (u user) LogValue() slog.Value {
+	return slog.GroupValue(slog.String("name", u.name), slog.Any("password", u.pw))
+}
+
+// loop is a LogValuer which resolves to itself.
+type loop struct{}
+
+func (l loop) LogValue() slog.Value { return slog.AnyValue(l) }
+
+func TestLogValuer(t *testing.T) {
+	var buf bytes.Buffer
+	l := slog.New(slogcolor.NewHandler(&buf, &slogcolor.Options{
+		Level:   slog.LevelInfo,
+		NoColor: true,
+		NoTime:  true,
+		ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+			if a.Key == "token" {
+				a.Value = slog.AnyValue(password("secret"))
+			}
+			return a
+		},
+	})).With("pw", password("bound"))
+	l.Info("login", "user", user{"ann", "hunter2"}, "token", "abc")
+	l.Info("cycle", "loop", loop{})
+
+	lines := strings.Split(buf.String(), "\n")
+	if want := "INFO  login pw=*** user.name=ann user.password=*** token=***"; lines[0] != want {
+		t.Errorf("got %q, want %q", lines[0], want)
+	}
+	if !strings.HasPrefix(lines[1], "INFO  cycle pw=*** loop=LogValue called too many times") {
+		t.Errorf("got %q, want the error of slog.Value.Resolve", lines[1])
+	}
+}
+
+func TestAttrOrder(t *testing.T) {
+	for _, tt := range []struct {
+		opts slogcolor.Options
+		want string
+	}{
+		{slogcolor.Options{SortAttrs: true}, "INFO  msg a=1 b=2 http.method=GET http.status=200 id=7 z=3\n"},
+		{slogcolor.Options{AttrOrder: []string{"id", "http"}}, "INFO  msg id=7 http.method=GET http.status=200 a=1 b=2 z=3\n"},
+		{slogcolor.Options{AttrOrder: []string{"http.status", "missing", "z"}}, "INFO  msg http.status=200 z=3 a=1 b=2 http.method=GET id=7\n"},
+	} {
+		opts := tt.opts
+		opts.Level = slog.LevelInfo
+		opts.NoColor = true
+		opts.NoTime = true
+
+		// the same attributes in a different order give the same output
+		for _, args := range [][]any{
+			{"z", 3, "id", 7, slog.Group("http", "status", 200, "method", "GET"), "a", 1},
+			{"a", 1, slog.Group("http", "method", "GET", "status", 200), "id", 7, "z", 3},
+		} {
+			var buf bytes.Buffer
+			slog.New(slogcolor.NewHandler(&buf, &opts)).With("b", 2).Info("msg", args...)
+			if got := buf.String(); got != tt.want {
+				t.Errorf("AttrOrder %v: got %q, want %q", opts.AttrOrder, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestMsgLength(t *testing.T) {
+	var buf bytes.Buffer
+	l := slog.New(slogcolor.NewHandler(&buf, &slogcolor.Options{Level: slog.LevelInfo, NoColor: true, NoTime: true, MsgLength: 10}))
+	l.Info("short", "k", "v")
+	l.Info("a much longer message", "k", "v")
+	l.Info("größenwahnsinnig", "k", "v")
+	l.Info("日本語のメッセージ", "k", "v")
+	l.Info("no attributes are never truncated")
+
+	// the first attribute always starts at column 17, also after wide characters
+	want := "INFO  short      k=v\n" +
+		"INFO  a much lo… k=v\n" +
+		"INFO  größenwah… k=v\n" +
+		"INFO  日本語の…  k=v\n" +
+		"INFO  no attributes are never truncated\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestMsgPrefixColor(t *testing.T) {
+	var buf bytes.Buffer
+	h := slogcolor.NewHandler(&buf, &slogcolor.Options{
+		Level:          slog.LevelInfo,
+		NoTime:         true,
+		ForceColor:     true,
+		Theme:          &slogcolor.Theme{},
+		MsgPrefix:      "[api] ",
+		MsgPrefixColor: color.New(color.FgMagenta),
+	})
+	slog.New(h).With("a", 1).WithGroup("g").Info("msg", "k", "v")
+	slog.New(slogcolor.NewHandler(&buf, &slogcolor.Options{Level: slog.LevelInfo, NoTime: true, ForceColor: true, Theme: &slogcolor.Theme{}})).Info("msg")
+
+	want := "INFO  \x1b[35m[api] \x1b[0mmsg a=1 g.k=v\nINFO  msg\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestSampleRate(t *testing.T) {
+	var buf bytes.Buffer
+	h := slogcolor.NewHandler(&buf, &slogcolor.Options{
+		Level:      slog.LevelDebug,
+		NoColor:    true,
+		NoTime:     true,
+		SampleRate: map[slog.Level]int{slog.LevelInfo: 3},
+	})
+	l := slog.New(h)
+	for i := range 7 {
+		l.Info("a", "i", i)
+		l.Info("b", "i", i)
+	}
+	l.Debug("unsampled")
+	l.Debug("unsampled")
+
+	want := "INFO  a i=0\nINFO  b i=0\nINFO  a i=3\nINFO  b i=3\nINFO  a i=6\nINFO  b i=6\nDEBUG unsampled\nDEBUG unsampled\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// clones count on their own
+	buf.Reset()
+	l.Info("a", "i", 7)
+	l.With("clone", true).Info("a", "i", 7)
+	if want := "INFO  a clone=true i=7\n"; buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
+
+func TestSampleWindow(t *testing.T) {
+	var buf bytes.Buffer
+	l := slog.New(slogcolor.NewHandler(&buf, &slogcolor.Options{
+		Level:        slog.LevelInfo,
+		NoColor:      true,
+		NoTime:       true,
+		SampleRate:   map[slog.Level]int{slog.LevelInfo: 100},
+		SampleWindow: 20 * time.Millisecond,
+	}))
+	l.Info("a", "i", 0)
+	l.Info("a", "i", 1)
+	time.Sleep(30 * time.Millisecond)
+	l.Info("a", "i", 2)
+
+	want := "INFO  a i=0\nINFO  a i=2\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestSampleRateMaxKeys(t *testing.T) {
+	var buf bytes.Buffer
+	l := slog.New(slogcolor.NewHandler(&buf, &slogcolor.Options{
+		Level:      slog.LevelInfo,
+		NoColor:    true,
+		NoTime:     true,
+		SampleRate: map[slog.Level]int{slog.LevelInfo: 2},
+	}))
+	l.Info("a", "i", 0)
+	l.Info("a", "i", 1)
+	for i := range 10000 {
+		l.Info("msg " + strconv.Itoa(i))
+	}
+	l.Info("a", "i", 2) // counted again from the start after the reset
+
+	if got := strings.Count(buf.String(), "INFO  a "); got != 2 {
+		t.Errorf("got %d records of a, want 2", got)
+	}
+	if got := strings.Count(buf.String(), "\n"); got != 10002 {
+		t.Errorf("got %d records, want 10002", got)
+	}
+}
+
+func TestTimeValues(t *testing.T) {
+	tm := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+	for _, tt := range []struct {
+		opts slogcolor.Options
+		want string
+	}{
+		{slogcolor.Options{}, "INFO  msg d=1h30m0s ms=250ms t=2024-01-02 15:04:05\n"},
+		{slogcolor.Options{TimeFormat: time.RFC3339, TimeLocation: time.FixedZone("CET", 3600)}, "INFO  msg d=1h30m0s ms=250ms t=2024-01-02T16:04:05+01:00\n"},
+		{slogcolor.Options{RawTimeValues: true}, "INFO  msg d=5400000000000 ms=250000000 t=2024-01-02 15:04:05 +0000 UTC\n"},
+	} {
+		var buf bytes.Buffer
+		opts := tt.opts
+		opts.Level = slog.LevelInfo
+		opts.NoColor = true
+		opts.NoTime = true
+		slog.New(slogcolor.NewHandler(&buf, &opts)).Info("msg", "d", 90*time.Minute, "ms", 250*time.Millisecond, "t", tm)
+		if got := buf.String(); got != tt.want {
+			t.Errorf("got %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestEscapeNewlines(t *testing.T) {
+	for _, tt := range []struct {
+		escape bool
+		want   string
+	}{
+		{true, "INFO  first\\nsecond k=a\\r\\nb err=line1\\nline2\n"},
+		{false, "INFO  first\nsecond k=a\r\nb err=line1\nline2\n"},
+	} {
+		var buf bytes.Buffer
+		slog.New(slogcolor.NewHandler(&buf, &slogcolor.Options{
+			Level:          slog.LevelInfo,
+			NoColor:        true,
+			NoTime:         true,
+			EscapeNewlines: tt.escape,
+		})).Info("first\nsecond", "k", "a\r\nb", "err", errors.New("line1\nline2"))
+		if got := buf.String(); got != tt.want {
+			t.Errorf("EscapeNewlines %v: got %q, want %q", tt.escape, got, tt.want)
+		}
+	}
+}
+
+func TestQuoteValues(t *testing.T) {
+	var buf bytes.Buffer
+	slog.New(slogcolor.NewHandler(&buf, &slogcolor.Options{
+		Level:       slog.LevelInfo,
+		NoColor:     true,
+		NoTime:      true,
+		QuoteValues: true,
+	})).Info("msg", "plain", "value", "spaced", "hello world", "quoted", `say "hi"`, "eq", "a=b", "multi", "a\nb", "n", 1, "empty", "")
+
+	want := `INFO  msg plain=value spaced="hello world" quoted="say \"hi\"" eq="a=b" multi="a\nb" n=1 empty=` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	buf.Reset()
+	slog.New(slogcolor.NewHandler(&buf, &slogcolor.Options{
+		Level:       slog.LevelInfo,
+		NoTime:      true,
+		ForceColor:  true,
+		Theme:       &slogcolor.Theme{},
+		StringColor: color.New(color.FgGreen),
+		QuoteValues: true,
+	})).Info("msg", "k", "hello world")
+	if want := "INFO  msg k=\x1b[32m\"hello world\"\x1b[0m\n"; buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
+
+func TestNoColorGolden(t *testing.T) {
+	var buf bytes.Buffer
+	h := slogcolor.NewHandler(&buf, &slogcolor.Options{
+		Level:        slog.LevelDebug,
+		NoColor:      true,
+		ForceColor:   true,
+		SrcFileMode:  slogcolor.LongFile,
+		SrcHyperlink: true,
+		MsgPrefix:    "\x1b[97m| \x1b[0m",
+		LevelTags:    map[slog.Level]string{slog.LevelWarn: "\x1b[43mWARN \x1b[0m"},
+		LevelIcons:   map[slog.Level]string{slog.LevelError: "🔥"},
+		IconMode:     slogcolor.IconPrepend,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.SourceKey && groups == nil {
+				return slog.Any(a.Key, &slog.Source{Function: "main.main", File: "/src/app/main.go", Line: 42})
+			}
+			return a
+		},
+	})
+	l := h.WithAttrs([]slog.Attr{slog.String("app", "srv")}).WithGroup("req")
+
+	tm := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+	for _, level := range []slog.Level{slog.LevelDebug, slog.LevelInfo, slog.LevelWarn, slog.LevelError, slog.Level(12)} {
+		r := slog.NewRecord(tm, level, "request \x1b[1mdone\x1b[0m", callerPC())
+		r.AddAttrs(slog.Int("status", 200), slog.Any("err", io.EOF), slog.Group("client", "ip", "::1"))
+		if err := l.Handle(context.Background(), r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	const golden = "2024-01-02 15:04:05 DEBUG    /src/app/main.go:42 | request done app=srv req.status=200 req.err=EOF req.client.ip=::1\n" +
+		"2024-01-02 15:04:05 INFO     /src/app/main.go:42 | request done app=srv req.status=200 req.err=EOF req.client.ip=::1\n" +
+		"2024-01-02 15:04:05 WARN  /src/app/main.go:42 | request done app=srv req.status=200 req.err=EOF req.client.ip=::1\n" +
+		"2024-01-02 15:04:05 🔥 ERROR /src/app/main.go:42 | request done app=srv req.status=200 req.err=EOF req.client.ip=::1\n" +
+		"2024-01-02 15:04:05 ERROR+4  /src/app/main.go:42 | request done app=srv req.status=200 req.err=EOF req.client.ip=::1\n"
+	if got := buf.String(); got != golden {
+		t.Errorf("got\n%s\nwant\n%s", got, golden)
+	}
+}
+
+func TestRedactKeys(t *testing.T) {
+	var buf bytes.Buffer
+	h := slogcolor.NewHandler(&buf, &slogcolor.Options{
+		Level:      slog.LevelInfo,
+		NoColor:    true,
+		NoTime:     true,
+		RedactKeys: []string{"password", "Authorization", "user.token"},
+	})
+	l := slog.New(h).With("authorization", "Bearer secret").WithGroup("user")
+	l.Info("login", "name", "jozef", "TOKEN", "secret", "password", "kept")
+	slog.New(h).Info("login", "password", "secret", slog.Group("user", "password", "kept"))
+
+	want := "INFO  login authorization=[REDACTED] user.name=jozef user.TOKEN=[REDACTED] user.password=kept\n" +
+		"INFO  login password=[REDACTED] user.password=kept\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestHighlightKeys(t *testing.T) {
+	for _, noColor := range []bool{false, true} {
+		var buf bytes.Buffer
+		slog.New(slogcolor.NewHandler(&buf, &slogcolor.Options{
+			Level:         slog.LevelInfo,
+			NoTime:        true,
+			ForceColor:    true,
+			NoColor:       noColor,
+			Theme:         &slogcolor.Theme{},
+			KeyColor:      color.New(color.FgBlue),
+			StringColor:   color.New(color.FgGreen),
+			HighlightKeys: map[string]*color.Color{"req.request_id": color.New(color.FgHiYellow)},
+		})).Info("msg", "request_id", "top", slog.Group("req", "request_id", "abc", "path", "/"))
+
+		want := "INFO  msg" +
+			" \x1b[34mrequest_id=\x1b[0m\x1b[32mtop\x1b[0m" +
+			" \x1b[34mreq.request_id=\x1b[0m\x1b[93mabc\x1b[0m" +
+			" \x1b[34mreq.path=\x1b[0m\x1b[32m/\x1b[0m\n"
+		if noColor {
+			want = "INFO  msg request_id=top req.request_id=abc req.path=/\n"
+		}
+		if got := buf.String(); got != want {
+			t.Errorf("NoColor %v: got %q, want %q", noColor, got, want)
+		}
+	}
+}
+
+func TestRedactKeyPatterns(t *testing.T) {
+	var buf bytes.Buffer
+	slog.New(slogcolor.NewHandler(&buf, &slogcolor.Options{
+		Level:             slog.LevelInfo,
+		NoColor:           true,
+		NoTime:            true,
+		RedactKeys:        []string{"password"},
+		RedactKeyPatterns: []*regexp.Regexp{regexp.MustCompile(`^user\.\d+\.token$`)},
+	})).Info("login", "password", "secret", slog.Group("user", slog.Group("123", "token", "secret", "name", "jozef")),
+		slog.Group("admin", slog.Group("123", "token", "kept")))
+
+	want := "INFO  login password=[REDACTED] user.123.token=[REDACTED] user.123.name=jozef admin.123.token=kept\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestHighlightKeyPatterns(t *testing.T) {
+	var buf bytes.Buffer
+	slog.New(slogcolor.NewHandler(&buf, &slogcolor.Options{
+		Level:         slog.LevelInfo,
+		NoTime:        true,
+		ForceColor:    true,
+		Theme:         &slogcolor.Theme{},
+		StringColor:   color.New(color.FgGreen),
+		HighlightKeys: map[string]*color.Color{"user.1.id": color.New(color.FgHiYellow)},
+		HighlightKeyPatterns: map[*regexp.Regexp]*color.Color{
+			regexp.MustCompile(`^user\.\d+\.`):     color.New(color.FgRed),
+			regexp.MustCompile(`(?i)^user\.\d+\.`): color.New(color.FgBlue),
+		},
+	})).Info("msg", slog.Group("user", slog.Group("1", "id", "a", "token", "b")), slog.Group("USER", slog.Group("2", "id", "c")),
+		slog.Group("users", "id", "d"))
+
+	want := "INFO  msg user.1.id=\x1b[93ma\x1b[0m user.1.token=\x1b[34mb\x1b[0m USER.2.id=\x1b[34mc\x1b[0m users.id=\x1b[32md\x1b[0m\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestFormatDurationAndBytes(t *testing.T) {
+	for _, tt := range []struct {
+		durations, bytes bool
+		want             string
+	}{
+		{false, false, "INFO  msg d=1.234567891s size_bytes=1258291 count=1258291\n"},
+		{true, false, "INFO  msg d=1.2s size_bytes=1258291 count=1258291\n"},
+		{false, true, "INFO  msg d=1.234567891s size_bytes=\"1.2 MiB\" count=1258291\n"},
+		{true, true, "INFO  msg d=1.2s size_bytes=\"1.2 MiB\" count=1258291\n"},
+	} {
+		var buf bytes.Buffer
+		slog.New(slogcolor.NewHandler(&buf, &slogcolor.Options{
+			Level:          slog.LevelInfo,
+			NoColor:        true,
+			NoTime:         true,
+			QuoteValues:    true,
+			FormatDuration: tt.durations,
+			FormatBytes:    tt.bytes,
+		})).Info("msg", "d", 1234567891*time.Nanosecond, "size_bytes", uint64(1258291), "count", 1258291)
+		if got := buf.String(); got != tt.want {
+			t.Errorf("FormatDuration %v, FormatBytes %v: got %q, want %q", tt.durations, tt.bytes, got, tt.want)
+		}
+	}
+}
+
+func TestWithOptions(t *testing.T) {
+	var buf bytes.Buffer
+	h := slogcolor.NewHandler(&buf, &slogcolor.Options{
+		Level:       slog.LevelInfo,
+		NoColor:     true,
+		NoTime:      true,
+		LevelLabels: map[slog.Level]string{slog.LevelWarn: "W"},
+	}).WithPrefix("app")
+	base := slog.New(h).With("k", "v")
+
+	db := slog.New(base.Handler().(*slogcolor.Handler).WithOptions(func(o *slogcolor.Options) {
+		o.Level = slog.LevelDebug
+		o.LevelLabels[slog.LevelWarn] = "WARNING"
+		o.LevelLabels[slog.LevelDebug] = "D"
+	})).With("db", "pg")
+
+	db.Debug("query")
+	db.Warn("slow")
+	base.Debug("dropped")
+	base.Warn("warn")
+
+	want := "app D     query k=v db=pg\n" +
+		"app WARNING slow k=v db=pg\n" +
+		"app W     warn k=v\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestContextExtractor(t *testing.T) {
+	for _, format := range []slogcolor.Format{slogcolor.FormatColor, slogcolor.FormatLogfmt} {
+		var buf bytes.Buffer
+		l := slog.New(slogcolor.NewHandler(&buf, &slogcolor.Options{
+			Level:   slog.LevelInfo,
+			NoColor: true,
+			NoTime:  true,
+			Format:  format,
+			ContextExtractor: func(ctx context.Context) []slog.Attr {
+				if id, ok := ctx.Value(requestIDKey{}).(string); ok {
+					return []slog.Attr{slog.String("request_id", id)}
+				}
+				return nil
+			},
+		})).With("app", "srv").WithGroup("http")
+
+		ctx := context.WithValue(t.Context(), requestIDKey{}, "abc123")
+		l.InfoContext(ctx, "request", "status", 200)
+		l.Info("no request")
+
+		want := "INFO  request app=srv request_id=abc123 http.status=200\nINFO  no request app=srv\n"
+		if format == slogcolor.FormatLogfmt {
+			want = "level=INFO msg=request app=srv request_id=abc123 http.status=200\nlevel=INFO msg=\"no request\" app=srv\n"
+		}
+		if got := buf.String(); got != want {
+			t.Errorf("format %d: got %q, want %q", format, got, want)
+		}
+	}
+}
+
+func TestSetLevel(t *testing.T) {
+	var buf syncBuffer
+	h := slogcolor.NewHandler(&buf, &slogcolor.Options{Level: new(slog.LevelVar), NoColor: true, NoTime: true})
+	l := slog.New(h).With("app", "srv")
+
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+				h.SetLevel([]slog.Level{slog.LevelDebug, slog.LevelError}[i%2])
+			}
+		}
+	}()
+	var logging sync.WaitGroup
+	for range 10 {
+		logging.Add(1)
+		go func() {
+			defer logging.Done()
+			for range 100 {
+				l.Info("msg")
+				h.Enabled(context.Background(), slog.LevelWarn)
+			}
+		}()
+	}
+	logging.Wait()
+	close(stop)
+	wg.Wait()
+
+	h.SetLevel(slog.LevelWarn)
+	if h.Enabled(context.Background(), slog.LevelInfo) || !h.Enabled(context.Background(), slog.LevelWarn) {
+		t.Error("SetLevel(LevelWarn) not applied")
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n") {
+		if line != "" && line != "INFO  msg app=srv" {
+			t.Errorf("unexpected line %q", line)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("SetLevel did not panic without a LevelVar")
+		}
+	}()
+	slogcolor.NewHandler(io.Discard, &slogcolor.Options{Level: slog.LevelInfo}).SetLevel(slog.LevelDebug)
+}
+
+func TestStyles(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+
+	color.NoColor = false
+	for _, tt := range []struct {
+		got, want string
+	}{
+		{slogcolor.Bold("x"), "\x1b[1mx\x1b[22m"},
+		{slogcolor.Dim("x"), "\x1b[2mx\x1b[22m"},
+		{slogcolor.Underline("x"), "\x1b[4mx\x1b[24m"},
+		{slogcolor.Colorize("x", "1;31"), "\x1b[1;31mx\x1b[0m"},
+	} {
+		if len(tt.got) <= len("x") || tt.got != tt.want {
+			t.Errorf("got %q, want %q", tt.got, tt.want)
+		}
+	}
+
+	color.NoColor = true
+	for _, got := range []string{slogcolor.Bold("x"), slogcolor.Dim("x"), slogcolor.Underline("x"), slogcolor.Colorize("x", "31")} {
+		if got != "x" {
+			t.Errorf("got %q with NoColor, want %q", got, "x")
+		}
+	}
+}
 
-	for i := 0; i < b.N; i++ {
-		b.StartTimer()
-		l.Info("benchmarking", "i", i)
-		b.StopTimer()
+func TestDedupeKeys(t *testing.T) {
+	for _, tt := range []struct {
+		dedupe bool
+		want   string
+	}{
+		{false, "INFO  msg id=1 app=srv g.k=a id=2 g.k=b\n"},
+		{true, "INFO  msg app=srv id=2 g.k=b\n"},
+	} {
+		var buf bytes.Buffer
+		h := slogcolor.NewHandler(&buf, &slogcolor.Options{Level: slog.LevelInfo, NoColor: true, NoTime: true, DedupeKeys: tt.dedupe})
+		slog.New(h).With("id", 1, "app", "srv", slog.Group("g", "k", "a")).Info("msg", "id", 2, slog.Group("g", "k", "b"))
+		if got := buf.String(); got != tt.want {
+			t.Errorf("DedupeKeys %v: got %q, want %q", tt.dedupe, got, tt.want)
+		}
 	}
 }
@@ -0,0 +1,76 @@
+package slogcolor
+
+import (
+	"log/slog"
+	"sync"
+)
+
+// hookQueueSize is the number of calls of [Options.AfterHandle] which can be pending with AsyncHook,
+// before further calls are dropped.
+const hookQueueSize = 1024
+
+// hookCall is a pending call of [Options.AfterHandle].
+type hookCall struct {
+	fn  func(r slog.Record, err error)
+	r   slog.Record
+	err error
+}
+
+// hookPool calls [Options.AfterHandle] in worker goroutines, see [Options.AsyncHook].
+// It is shared by all clones of the handler, also by those of WithOptions with another AfterHandle.
+type hookPool struct {
+	mu     sync.RWMutex // guards closed, so that no call is queued after calls is closed
+	closed bool
+	calls  chan hookCall
+	wg     sync.WaitGroup
+}
+
+// newHookPool starts workers goroutines calling the hooks.
+func newHookPool(workers int) *hookPool {
+	p := &hookPool{calls: make(chan hookCall, hookQueueSize)}
+	p.wg.Add(workers)
+	for range workers {
+		go func() {
+			defer p.wg.Done()
+			for c := range p.calls {
+				c.fn(c.r, c.err)
+			}
+		}()
+	}
+	return p
+}
+
+// call queues a call of fn for r, which must not be modified afterwards. It never blocks:
+// the call is dropped if the queue is full, and fn is called directly once the pool is closed.
+func (p *hookPool) call(fn func(r slog.Record, err error), r slog.Record, err error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		fn(r, err)
+		return
+	}
+	select {
+	case p.calls <- hookCall{fn, r, err}:
+	default:
+	}
+}
+
+// close waits for the pending calls and stops the workers.
+func (p *hookPool) close() {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.calls)
+	}
+	p.mu.Unlock()
+	p.wg.Wait()
+}
+
+// afterHandle calls [Options.AfterHandle] for r and the error of writing it.
+func (h *Handler) afterHandle(r slog.Record, err error) {
+	if h.hooks == nil {
+		h.opts.AfterHandle(r, err)
+		return
+	}
+	h.hooks.call(h.opts.AfterHandle, r.Clone(), err)
+}
@@ -0,0 +1,128 @@
+package slogcolor_test
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/geomyidia/slogcolor"
+)
+
+// errWriter fails every write.
+type errWriter struct{}
+
+var errWrite = errors.New("disk full")
+
+func (errWriter) Write([]byte) (int, error) { return 0, errWrite }
+
+func TestAfterHandle(t *testing.T) {
+	counts := make(map[slog.Level]int)
+	var errs []error
+	opts := &slogcolor.Options{
+		Level:      slog.LevelInfo,
+		SampleRate: map[slog.Level]int{slog.LevelWarn: 2},
+		AfterHandle: func(r slog.Record, err error) {
+			counts[r.Level]++
+			errs = append(errs, err)
+		},
+	}
+	l := slog.New(slogcolor.NewHandler(io.Discard, opts)).With("app", "srv")
+	l.Debug("dropped")
+	l.Info("info")
+	l.Warn("warn")
+	l.Warn("warn") // sampled out
+	l.Error("error")
+	slog.New(slogcolor.NewHandler(errWriter{}, opts)).Error("failed")
+
+	if counts[slog.LevelDebug] != 0 || counts[slog.LevelInfo] != 1 || counts[slog.LevelWarn] != 1 || counts[slog.LevelError] != 2 {
+		t.Errorf("got counts %v", counts)
+	}
+	if len(errs) != 4 || errs[0] != nil || !errors.Is(errs[3], errWrite) {
+		t.Errorf("got errors %v, want the write error for the last record", errs)
+	}
+}
+
+func TestAsyncHook(t *testing.T) {
+	release := make(chan struct{})
+	var mu sync.Mutex
+	var msgs []string
+	h := slogcolor.NewHandler(io.Discard, &slogcolor.Options{
+		Level:       slog.LevelInfo,
+		AsyncHook:   true,
+		HookWorkers: 2,
+		AfterHandle: func(r slog.Record, err error) {
+			<-release
+			mu.Lock()
+			msgs = append(msgs, r.Message)
+			mu.Unlock()
+		},
+	})
+
+	done := make(chan struct{})
+	go func() {
+		l := slog.New(h)
+		for range 10 {
+			l.Info("msg")
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Handle blocked on the slow hook")
+	}
+
+	close(release)
+	if err := h.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if len(msgs) != 10 {
+		t.Errorf("got %d hook calls after Close, want 10", len(msgs))
+	}
+
+	slog.New(h).Info("after close")
+	if len(msgs) != 11 {
+		t.Errorf("hook was not called directly after Close")
+	}
+}
+
+func TestAsyncHookWithOptions(t *testing.T) {
+	release := make(chan struct{})
+	var mu sync.Mutex
+	var msgs []string
+	h := slogcolor.NewHandler(io.Discard, &slogcolor.Options{
+		Level:       slog.LevelInfo,
+		AsyncHook:   true,
+		HookWorkers: 2,
+		AfterHandle: func(r slog.Record, err error) {
+			<-release
+			mu.Lock()
+			msgs = append(msgs, r.Message)
+			mu.Unlock()
+		},
+	})
+
+	before := runtime.NumGoroutine()
+	var clones []*slogcolor.Handler
+	for range 10 {
+		clones = append(clones, h.WithOptions(func(o *slogcolor.Options) { o.HookWorkers = 8 }))
+	}
+	if n := runtime.NumGoroutine(); n > before {
+		t.Errorf("got %d goroutines after WithOptions, %d before", n, before)
+	}
+
+	for _, c := range clones {
+		slog.New(c).Info("clone")
+	}
+	close(release)
+	if err := h.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if len(msgs) != 10 {
+		t.Errorf("got %d hook calls of the clones after Close, want 10", len(msgs))
+	}
+}
@@ -0,0 +1,64 @@
+package slogcolor
+
+import (
+	"log/slog"
+	"strconv"
+	"time"
+)
+
+// formatDuration formats d for [Options.FormatDuration] in the largest fitting unit with one decimal,
+// for example 850ns, 1.5µs, 12.3ms, 4.2s or 1m2.3s.
+func formatDuration(d time.Duration) string {
+	sign := ""
+	if d < 0 {
+		sign, d = "-", -d
+	}
+	var s string
+	switch {
+	case d < time.Microsecond:
+		s = strconv.FormatInt(int64(d), 10) + "ns"
+	case d < time.Millisecond:
+		s = formatUnit(float64(d)/float64(time.Microsecond), "µs")
+	case d < time.Second:
+		s = formatUnit(float64(d)/float64(time.Millisecond), "ms")
+	case d < time.Minute:
+		s = formatUnit(d.Seconds(), "s")
+	default:
+		s = d.Round(100 * time.Millisecond).String()
+	}
+	return sign + s
+}
+
+// formatUnit formats x with at most one decimal, followed by unit.
+func formatUnit(x float64, unit string) string {
+	return strconv.FormatFloat(float64(int64(x*10+0.5))/10, 'f', -1, 64) + unit
+}
+
+// byteUnits are the binary units of [formatBytes].
+var byteUnits = []string{"KiB", "MiB", "GiB", "TiB", "PiB", "EiB"}
+
+// formatBytes formats the byte count n for [Options.FormatBytes] in the largest fitting binary unit,
+// for example 512 B or 1.2 MiB.
+func formatBytes(n uint64) string {
+	if n < 1024 {
+		return strconv.FormatUint(n, 10) + " B"
+	}
+	x := float64(n)
+	unit := -1
+	for x >= 1024 && unit < len(byteUnits)-1 {
+		x /= 1024
+		unit++
+	}
+	return strconv.FormatFloat(x, 'f', 1, 64) + " " + byteUnits[unit]
+}
+
+// byteCount returns the value of an int or uint attribute as a byte count, or false if it is negative.
+func byteCount(v slog.Value) (uint64, bool) {
+	if v.Kind() == slog.KindUint64 {
+		return v.Uint64(), true
+	}
+	if n := v.Int64(); n >= 0 {
+		return uint64(n), true
+	}
+	return 0, false
+}
@@ -0,0 +1,45 @@
+package slogcolor
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFormatDuration(t *testing.T) {
+	for _, tt := range []struct {
+		d    time.Duration
+		want string
+	}{
+		{0, "0ns"},
+		{850 * time.Nanosecond, "850ns"},
+		{1500 * time.Nanosecond, "1.5µs"},
+		{12 * time.Microsecond, "12µs"},
+		{12345 * time.Microsecond, "12.3ms"},
+		{1234567891 * time.Nanosecond, "1.2s"},
+		{62300 * time.Millisecond, "1m2.3s"},
+		{time.Hour + 2*time.Minute, "1h2m0s"},
+		{-1500 * time.Microsecond, "-1.5ms"},
+	} {
+		if got := formatDuration(tt.d); got != tt.want {
+			t.Errorf("formatDuration(%d) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	for _, tt := range []struct {
+		n    uint64
+		want string
+	}{
+		{0, "0 B"},
+		{512, "512 B"},
+		{1024, "1.0 KiB"},
+		{1258291, "1.2 MiB"},
+		{5 << 30, "5.0 GiB"},
+		{1<<64 - 1, "16.0 EiB"},
+	} {
+		if got := formatBytes(tt.n); got != tt.want {
+			t.Errorf("formatBytes(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
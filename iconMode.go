@@ -0,0 +1,12 @@
+package slogcolor
+
+// IconMode is the way the icons of [Options.LevelIcons] are combined with the level labels.
+type IconMode int
+
+const (
+	// IconReplace shows the icon instead of the level label (for example 🔥).
+	IconReplace IconMode = iota
+
+	// IconPrepend shows the icon before the level label (for example 🔥 ERROR).
+	IconPrepend
+)
@@ -0,0 +1,88 @@
+package slogcolor
+
+import (
+	"cmp"
+	"encoding/json"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/fatih/color"
+)
+
+// default colors of the JSON tokens, used if the handler has no color for the value kind, see [Options.HighlightJSON]
+var (
+	jsonStringColor  = color.New(color.FgGreen)
+	jsonNumberColor  = color.New(color.FgYellow)
+	jsonLiteralColor = color.New(color.FgMagenta)
+)
+
+// highlightJSON colors the tokens of the JSON object or array s, keeping its formatting.
+// It returns false if s is not a valid JSON object or array, or not valid UTF-8.
+func (h *Handler) highlightJSON(s string) (string, bool) {
+	if t := strings.TrimLeft(s, " \t\r\n"); t == "" || (t[0] != '{' && t[0] != '[') {
+		return "", false
+	}
+	if !utf8.ValidString(s) || !json.Valid([]byte(s)) {
+		return "", false
+	}
+
+	// containers tracks the open objects and arrays, with the number of tokens read in each,
+	// to tell the keys of an object from its values
+	type container struct {
+		object bool
+		n      int
+	}
+	var containers []container
+
+	var b strings.Builder
+	dec := json.NewDecoder(strings.NewReader(s))
+	dec.UseNumber()
+	start := 0
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", false
+		}
+		// the raw token with the separator and whitespace before it
+		end := int(dec.InputOffset())
+		raw := s[start:end]
+		start = end
+		literal := strings.TrimLeft(raw, " \t\r\n,:")
+		b.WriteString(raw[:len(raw)-len(literal)])
+
+		isKey := false
+		if d, ok := tok.(json.Delim); ok && (d == '}' || d == ']') {
+			containers = containers[:len(containers)-1]
+		} else if len(containers) > 0 {
+			top := &containers[len(containers)-1]
+			isKey = top.object && top.n%2 == 0
+			top.n++
+		}
+
+		switch tok := tok.(type) {
+		case json.Delim:
+			if tok == '{' || tok == '[' {
+				containers = append(containers, container{object: tok == '{'})
+			}
+			b.WriteString(literal)
+		case string:
+			if isKey {
+				b.WriteString(h.sprint(h.opts.KeyColor, literal))
+			} else {
+				b.WriteString(h.sprint(cmp.Or(h.opts.StringColor, jsonStringColor), literal))
+			}
+		case json.Number:
+			b.WriteString(h.sprint(cmp.Or(h.opts.NumberColor, jsonNumberColor), literal))
+		case nil:
+			b.WriteString(h.sprint(cmp.Or(h.opts.NullColor, h.opts.BoolColor, jsonLiteralColor), literal))
+		default: // bool
+			b.WriteString(h.sprint(cmp.Or(h.opts.BoolColor, jsonLiteralColor), literal))
+		}
+	}
+	b.WriteString(s[start:]) // trailing whitespace
+	return b.String(), true
+}
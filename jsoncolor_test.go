@@ -0,0 +1,32 @@
+package slogcolor
+
+import (
+	"io"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestHighlightJSON(t *testing.T) {
+	h := NewHandler(io.Discard, &Options{ForceColor: true, Theme: &Theme{}, KeyColor: color.New(color.FgCyan)})
+	for _, tt := range []struct {
+		s, want string
+		ok      bool
+	}{
+		{`{"a":1,"b":[true,null,"x"]}`, "{\x1b[36m\"a\"\x1b[0m:\x1b[33m1\x1b[0m,\x1b[36m\"b\"\x1b[0m:[\x1b[35mtrue\x1b[0m,\x1b[35mnull\x1b[0m,\x1b[32m\"x\"\x1b[0m]}", true},
+		{`[ 1.5e3, {"k": "v"} ] `, "[ \x1b[33m1.5e3\x1b[0m, {\x1b[36m\"k\"\x1b[0m: \x1b[32m\"v\"\x1b[0m} ] ", true},
+		{`{}`, `{}`, true},
+		{`"string"`, "", false},
+		{`42`, "", false},
+		{`{"a":`, "", false},
+		{`[1,2`, "", false},
+		{`{"a":1} {"b":2}`, "", false},
+		{"{\"a\":\"\xff\"}", "", false},
+		{``, "", false},
+	} {
+		got, ok := h.highlightJSON(tt.s)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("highlightJSON(%q) = %q, %v, want %q, %v", tt.s, got, ok, tt.want, tt.ok)
+		}
+	}
+}
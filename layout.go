@@ -0,0 +1,13 @@
+package slogcolor
+
+// Layout is the arrangement of the attributes of a record, see [Options.Layout].
+type Layout int
+
+const (
+	// LayoutCompact prints the attributes after the message, on the same line.
+	LayoutCompact Layout = iota
+
+	// LayoutExpanded prints each attribute on its own indented line beneath the message, as "key: value".
+	// The keys are qualified with their groups, whatever the GroupStyle.
+	LayoutExpanded
+)
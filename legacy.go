@@ -0,0 +1,45 @@
+package slogcolor
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// legacyTimestamp matches the date and time prefixes of the standard log package, see [log.LstdFlags].
+var legacyTimestamp = regexp.MustCompile(`^(\d{4}/\d{2}/\d{2} )?(\d{2}:\d{2}:\d{2}(\.\d{6})? )?`)
+
+// NewLegacyWriter returns a writer that forwards the lines written by the standard log package to logger
+// as records at level, so that they are formatted like the other records:
+//
+//	log.SetOutput(slogcolor.NewLegacyWriter(slog.Default(), slog.LevelInfo))
+//
+// The date and time written by the log package are stripped, the record has the time of the write instead.
+// The rest of the line is the message as is, it is not parsed for attributes.
+func NewLegacyWriter(logger *slog.Logger, level slog.Level) io.Writer {
+	return &legacyWriter{h: logger.Handler(), level: level}
+}
+
+// legacyWriter is the writer of [NewLegacyWriter].
+type legacyWriter struct {
+	h     slog.Handler
+	level slog.Level
+}
+
+// Write implements io.Writer. The log package writes every entry with a single call.
+func (w *legacyWriter) Write(p []byte) (int, error) {
+	ctx := context.Background()
+	if !w.h.Enabled(ctx, w.level) {
+		return len(p), nil
+	}
+	msg := strings.TrimSuffix(string(p), "\n")
+	msg = msg[len(legacyTimestamp.FindString(msg)):]
+	// the caller of the log package is unknown, the source would be the log package itself
+	if err := w.h.Handle(ctx, slog.NewRecord(time.Now(), w.level, msg, 0)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -0,0 +1,43 @@
+package slogcolor_test
+
+import (
+	"bytes"
+	"log"
+	"log/slog"
+	"testing"
+
+	"github.com/geomyidia/slogcolor"
+	"github.com/geomyidia/slogcolor/slogcolortest"
+)
+
+func TestLegacyWriter(t *testing.T) {
+	var buf bytes.Buffer
+	l := slog.New(slogcolor.NewHandler(&buf, &slogcolor.Options{Level: slog.LevelInfo, NoColor: true, NoTime: true}))
+	for _, flags := range []int{0, log.LstdFlags, log.Ldate | log.Lmicroseconds} {
+		buf.Reset()
+		legacy := log.New(slogcolor.NewLegacyWriter(l.With("lib", "old"), slog.LevelWarn), "", flags)
+		legacy.Printf("retrying user=%s attempt=%d", "bob", 2)
+		if got, want := buf.String(), "WARN  retrying user=bob attempt=2 lib=old\n"; got != want {
+			t.Errorf("flags %d: got %q, want %q", flags, got, want)
+		}
+	}
+
+	buf.Reset()
+	log.New(slogcolor.NewLegacyWriter(l, slog.LevelDebug), "", log.LstdFlags).Print("hidden")
+	if got := buf.String(); got != "" {
+		t.Errorf("got %q below the level, want nothing", got)
+	}
+}
+
+func TestLegacyWriterMessage(t *testing.T) {
+	h, sink := slogcolortest.NewHandler(t, &slogcolor.Options{Level: slog.LevelInfo})
+	log.New(slogcolor.NewLegacyWriter(slog.New(h), slog.LevelInfo), "", log.LstdFlags).Print("key=value a=b")
+
+	records := sink.Records()
+	if len(records) != 1 {
+		t.Fatalf("got %d records, want 1", len(records))
+	}
+	if r := records[0]; r.Message != "key=value a=b" || r.NumAttrs() != 0 || r.Level != slog.LevelInfo {
+		t.Errorf("got %s record %q with %d attrs, want the line as the message", r.Level, r.Message, r.NumAttrs())
+	}
+}
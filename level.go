@@ -0,0 +1,139 @@
+package slogcolor
+
+import (
+	"fmt"
+	"log/slog"
+	"maps"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// LevelDef defines the label and color of a level, see [Options.CustomLevels].
+type LevelDef struct {
+	// Level is the level, for example slog.Level(-8) for TRACE.
+	Level slog.Level
+
+	// Name is the label of the level.
+	Name string
+
+	// Color is the color of the level tag. If nil, the color of the nearest lower level of the theme is used.
+	Color *color.Color
+}
+
+// defaultLevelWidth is the width of the default level tags.
+const defaultLevelWidth = 5
+
+// levelText returns the uncolored level name, padded to line up with the default level tags.
+func levelText(l slog.Level) string {
+	return fmt.Sprintf("%-*s", defaultLevelWidth, l.String())
+}
+
+// mergeCustomLevels merges [Options.CustomLevels] into copies of [Options.LevelLabels] and [Options.LevelColors].
+func (h *Handler) mergeCustomLevels() {
+	if len(h.opts.CustomLevels) == 0 {
+		return
+	}
+	labels := make(map[slog.Level]string, len(h.opts.LevelLabels)+len(h.opts.CustomLevels))
+	colors := make(map[slog.Level]*color.Color, len(h.opts.LevelColors)+len(h.opts.CustomLevels))
+	for _, def := range h.opts.CustomLevels {
+		labels[def.Level] = def.Name
+		if def.Color != nil {
+			colors[def.Level] = def.Color
+		}
+	}
+	maps.Copy(labels, h.opts.LevelLabels)
+	maps.Copy(colors, h.opts.LevelColors)
+	h.opts.LevelLabels, h.opts.LevelColors = labels, colors
+}
+
+// levelTag returns the level tag for l.
+func (h *Handler) levelTag(l slog.Level) string {
+	if tag, ok := h.opts.LevelTags[l]; ok {
+		return tag
+	}
+	return h.buildLevelTag(l)
+}
+
+// buildLevelTag builds the level tag for l from [Options.LevelLabels], [Options.LevelWidth] and the level color.
+func (h *Handler) buildLevelTag(l slog.Level) string {
+	return h.sprint(h.levelColor(l), h.padLevelLabel(h.levelLabel(l)))
+}
+
+// levelLabel returns the uncolored label of l, combined with its icon according to [Options.IconMode].
+func (h *Handler) levelLabel(l slog.Level) string {
+	label, ok := h.opts.LevelLabels[l]
+	if !ok {
+		label = l.String()
+	}
+	if icon, ok := h.opts.LevelIcons[l]; ok {
+		if h.opts.IconMode == IconPrepend {
+			return icon + " " + label
+		}
+		return icon
+	}
+	return label
+}
+
+// padLevelLabel pads or truncates label to [Options.LevelWidth] columns. If it is not set,
+// label is padded to the width of the longest level label if [Options.PadLevelText] or [Options.LevelIcons] is set,
+// or to the width of the default level tags otherwise.
+func (h *Handler) padLevelLabel(label string) string {
+	n := displayWidth(label)
+	switch width := h.opts.LevelWidth; {
+	case width > 0 && n > width:
+		label = truncateWidth(label, width)
+		label += strings.Repeat(" ", width-displayWidth(label))
+	case width > 0:
+		label += strings.Repeat(" ", width-n)
+	case n < h.levelPad:
+		label += strings.Repeat(" ", h.levelPad-n)
+	}
+	return label
+}
+
+// longestLevelLabel returns the width of the longest label of the levels with a color, label or icon.
+func (h *Handler) longestLevelLabel() int {
+	width := 0
+	for _, levels := range []map[slog.Level]*color.Color{h.opts.Theme.Levels, h.opts.LevelColors} {
+		for l := range levels {
+			width = max(width, displayWidth(h.levelLabel(l)))
+		}
+	}
+	for _, levels := range []map[slog.Level]string{h.opts.LevelLabels, h.opts.LevelIcons} {
+		for l := range levels {
+			width = max(width, displayWidth(h.levelLabel(l)))
+		}
+	}
+	return width
+}
+
+// levelColor returns the color of the level tag for l. Levels without a color of their own
+// use the color of the nearest lower level of the theme, or the lowest one if there is none.
+func (h *Handler) levelColor(l slog.Level) *color.Color {
+	if c, ok := h.opts.LevelColors[l]; ok && c != nil {
+		return c
+	}
+
+	levels := h.opts.Theme.Levels
+	if c, ok := levels[l]; ok {
+		return c
+	}
+
+	var nearest, lowest *slog.Level
+	for k := range levels {
+		if k < l && (nearest == nil || k > *nearest) {
+			nearest = &k
+		}
+		if lowest == nil || k < *lowest {
+			lowest = &k
+		}
+	}
+	switch {
+	case nearest != nil:
+		return levels[*nearest]
+	case lowest != nil:
+		return levels[*lowest]
+	}
+	return nil
+}
@@ -0,0 +1,15 @@
+package slogcolor_test
+
+import (
+	"os"
+	"testing"
+)
+
+// TestMain clears the environment variables from which the handler detects the colors, so that the tests
+// do not depend on the terminal they are run in. The tests of the detection set them with t.Setenv.
+func TestMain(m *testing.M) {
+	for _, k := range []string{"COLORTERM", "TERM", "NO_COLOR"} {
+		os.Unsetenv(k)
+	}
+	os.Exit(m.Run())
+}
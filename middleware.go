@@ -0,0 +1,121 @@
+package slogcolor
+
+import (
+	"context"
+	"io"
+	"log/slog"
+)
+
+// Middleware wraps a handler to process the records before or after it, like HTTP middleware.
+type Middleware func(next slog.Handler) slog.Handler
+
+// NewHandlerWithMiddleware creates a new [Handler] with the specified options, wrapped in mw.
+// The first middleware is the outermost one, i.e. it sees every record first. If opts is nil, uses [DefaultOptions].
+func NewHandlerWithMiddleware(w io.Writer, opts *Options, mw ...Middleware) slog.Handler {
+	var h slog.Handler = NewHandler(w, opts)
+	for i := len(mw) - 1; i >= 0; i-- {
+		h = mw[i](h)
+	}
+	return h
+}
+
+// WithFieldRedaction returns a [Middleware] that replaces the values of the attributes with the given keys,
+// also in groups and in attributes added with WithAttrs, with an empty string.
+func WithFieldRedaction(keys ...string) Middleware {
+	set := make(map[string]struct{}, len(keys))
+	for _, k := range keys {
+		set[k] = struct{}{}
+	}
+	return func(next slog.Handler) slog.Handler {
+		return &redactionHandler{next: next, keys: set}
+	}
+}
+
+// redactionHandler is the handler of [WithFieldRedaction].
+type redactionHandler struct {
+	next slog.Handler
+	keys map[string]struct{}
+}
+
+// redact returns a with its value, or the values of its members if it is a group, redacted.
+func (h *redactionHandler) redact(a slog.Attr) slog.Attr {
+	if _, ok := h.keys[a.Key]; ok {
+		return slog.String(a.Key, "")
+	}
+	if v := a.Value.Resolve(); v.Kind() == slog.KindGroup {
+		members := v.Group()
+		redacted := make([]slog.Attr, len(members))
+		for i, m := range members {
+			redacted[i] = h.redact(m)
+		}
+		return slog.Attr{Key: a.Key, Value: slog.GroupValue(redacted...)}
+	}
+	return a
+}
+
+// Enabled implements slog.Handler.Enabled .
+func (h *redactionHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+// Handle implements slog.Handler.Handle .
+func (h *redactionHandler) Handle(ctx context.Context, r slog.Record) error {
+	r2 := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		r2.AddAttrs(h.redact(a))
+		return true
+	})
+	return h.next.Handle(ctx, r2)
+}
+
+// WithAttrs implements slog.Handler.WithAttrs .
+func (h *redactionHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		redacted[i] = h.redact(a)
+	}
+	return &redactionHandler{next: h.next.WithAttrs(redacted), keys: h.keys}
+}
+
+// WithGroup implements slog.Handler.WithGroup .
+func (h *redactionHandler) WithGroup(name string) slog.Handler {
+	return &redactionHandler{next: h.next.WithGroup(name), keys: h.keys}
+}
+
+// WithContextAttrs returns a [Middleware] that adds the attributes returned by extract for the context
+// passed to the logger, for example with [slog.InfoContext], to every record.
+func WithContextAttrs(extract func(ctx context.Context) []slog.Attr) Middleware {
+	return func(next slog.Handler) slog.Handler {
+		return &contextHandler{next: next, extract: extract}
+	}
+}
+
+// contextHandler is the handler of [WithContextAttrs].
+type contextHandler struct {
+	next    slog.Handler
+	extract func(ctx context.Context) []slog.Attr
+}
+
+// Enabled implements slog.Handler.Enabled .
+func (h *contextHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+// Handle implements slog.Handler.Handle .
+func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if attrs := h.extract(ctx); len(attrs) > 0 {
+		r = r.Clone()
+		r.AddAttrs(attrs...)
+	}
+	return h.next.Handle(ctx, r)
+}
+
+// WithAttrs implements slog.Handler.WithAttrs .
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{next: h.next.WithAttrs(attrs), extract: h.extract}
+}
+
+// WithGroup implements slog.Handler.WithGroup .
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{next: h.next.WithGroup(name), extract: h.extract}
+}
@@ -0,0 +1,68 @@
+package slogcolor_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"reflect"
+	"testing"
+
+	"github.com/geomyidia/slogcolor"
+)
+
+type requestIDKey struct{}
+
+func TestNewHandlerWithMiddleware(t *testing.T) {
+	var order []string
+	trace := func(name string) slogcolor.Middleware {
+		return func(next slog.Handler) slog.Handler {
+			return tracingHandler{Handler: next, handle: func() { order = append(order, name) }}
+		}
+	}
+
+	var buf bytes.Buffer
+	h := slogcolor.NewHandlerWithMiddleware(&buf, &slogcolor.Options{Level: slog.LevelInfo, NoColor: true, NoTime: true},
+		trace("outer"),
+		slogcolor.WithFieldRedaction("password", "token"),
+		slogcolor.WithContextAttrs(func(ctx context.Context) []slog.Attr {
+			if id, ok := ctx.Value(requestIDKey{}).(string); ok {
+				return []slog.Attr{slog.String("request_id", id), slog.String("token", "from-context")}
+			}
+			return nil
+		}),
+		trace("inner"),
+	)
+	l := slog.New(h).With("user", "bob", "password", "hunter2").WithGroup("req")
+
+	ctx := context.WithValue(context.Background(), requestIDKey{}, "abc")
+	l.InfoContext(ctx, "login", slog.Group("auth", "token", "secret", "scheme", "basic"))
+	l.Info("no context")
+
+	want := "INFO  login user=bob password= req.auth.token= req.auth.scheme=basic req.request_id=abc req.token=from-context\n" +
+		"INFO  no context user=bob password=\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if want := []string{"outer", "inner", "outer", "inner"}; !reflect.DeepEqual(order, want) {
+		t.Errorf("got order %v, want %v", order, want)
+	}
+}
+
+// tracingHandler calls handle for every record.
+type tracingHandler struct {
+	slog.Handler
+	handle func()
+}
+
+func (h tracingHandler) Handle(ctx context.Context, r slog.Record) error {
+	h.handle()
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h tracingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return tracingHandler{Handler: h.Handler.WithAttrs(attrs), handle: h.handle}
+}
+
+func (h tracingHandler) WithGroup(name string) slog.Handler {
+	return tracingHandler{Handler: h.Handler.WithGroup(name), handle: h.handle}
+}
@@ -0,0 +1,84 @@
+package slogcolor
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+)
+
+// multiHandler passes every record to several handlers.
+type multiHandler struct {
+	handlers []slog.Handler
+}
+
+// NewMultiHandler creates a handler that writes colored output to colorWriter and plain output to plainWriter,
+// for example colored logs on the terminal and plain logs in a file. The output to colorWriter is colored
+// even if it is not a terminal, unless color is disabled by opts or the NO_COLOR environment variable.
+// If opts is nil, uses [DefaultOptions]. The handler has the methods Flush and Close of [Handler],
+// which flush and close the handlers of both writers, for example with [Options.BufferSize].
+func NewMultiHandler(colorWriter io.Writer, plainWriter io.Writer, opts *Options) slog.Handler {
+	if opts == nil {
+		opts = DefaultOptions
+	}
+	colorOpts, plainOpts := *opts, *opts
+	colorOpts.ForceColor = true
+	plainOpts.NoColor = true
+
+	return &multiHandler{handlers: []slog.Handler{
+		NewHandler(colorWriter, &colorOpts),
+		NewHandler(plainWriter, &plainOpts),
+	}}
+}
+
+// Enabled implements slog.Handler.Enabled .
+func (h *multiHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, h := range h.handlers {
+		if h.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+// Handle implements slog.Handler.Handle .
+func (h *multiHandler) Handle(ctx context.Context, r slog.Record) error {
+	var errs []error
+	for _, h := range h.handlers {
+		if h.Enabled(ctx, r.Level) {
+			errs = append(errs, h.Handle(ctx, r.Clone()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Flush writes the buffered records of both writers, see [Handler.Flush].
+func (h *multiHandler) Flush() error {
+	return flushHandlers(h.handlers)
+}
+
+// Close implements io.Closer. It closes the handlers of both writers, see [Handler.Close].
+func (h *multiHandler) Close() error {
+	return closeHandlers(h.handlers)
+}
+
+// WithAttrs implements slog.Handler.WithAttrs .
+func (h *multiHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make([]slog.Handler, len(h.handlers))
+	for i, h := range h.handlers {
+		handlers[i] = h.WithAttrs(attrs)
+	}
+	return &multiHandler{handlers: handlers}
+}
+
+// WithGroup implements slog.Handler.WithGroup .
+func (h *multiHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	handlers := make([]slog.Handler, len(h.handlers))
+	for i, h := range h.handlers {
+		handlers[i] = h.WithGroup(name)
+	}
+	return &multiHandler{handlers: handlers}
+}
@@ -0,0 +1,57 @@
+package slogcolor_test
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/geomyidia/slogcolor"
+)
+
+// syncBuffer is a bytes.Buffer that is safe for concurrent use.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestNewMultiHandler(t *testing.T) {
+	var colored, plain syncBuffer
+	h := slogcolor.NewMultiHandler(&colored, &plain, &slogcolor.Options{Level: slog.LevelInfo, NoTime: true})
+	l := slog.New(h).With("app", "srv").WithGroup("req")
+
+	var wg sync.WaitGroup
+	for i := range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			l.Info("msg", "i", i)
+		}()
+	}
+	wg.Wait()
+
+	if n := strings.Count(colored.String(), "\n"); n != 10 {
+		t.Errorf("got %d colored lines, want 10", n)
+	}
+	if !strings.Contains(colored.String(), "\x1b[") {
+		t.Errorf("expected colored output, got %q", colored.String())
+	}
+	for _, line := range strings.SplitAfter(plain.String(), "\n")[:10] {
+		if !strings.HasPrefix(line, "INFO  msg app=srv req.i=") {
+			t.Errorf("unexpected plain line %q", line)
+		}
+	}
+}
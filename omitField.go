@@ -0,0 +1,18 @@
+package slogcolor
+
+// OmitField is a bitmask of built-in fields to leave out of the output.
+type OmitField int
+
+const (
+	// OmitTime omits the timestamp, same as [Options.NoTime].
+	OmitTime OmitField = 1 << iota
+
+	// OmitLevel omits the level tag.
+	OmitLevel
+
+	// OmitSource omits the source file info, regardless of [Options.SrcFileMode].
+	OmitSource
+
+	// OmitMessage omits the message and [Options.MsgPrefix].
+	OmitMessage
+)
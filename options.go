@@ -1,33 +1,107 @@
 package slogcolor
 
 import (
+	"context"
 	"log/slog"
+	"maps"
+	"regexp"
+	"slices"
 	"time"
 
 	"github.com/fatih/color"
 )
 
-// DefaultLevelTags are the default level tags.
-var DefaultLevelTags = map[slog.Level]string{
-	slog.LevelDebug: color.New(color.BgCyan, color.FgHiWhite).Sprint("DEBUG"),
-	slog.LevelInfo:  color.New(color.BgGreen, color.FgHiWhite).Sprint("INFO "),
-	slog.LevelWarn:  color.New(color.BgYellow, color.FgHiWhite).Sprint("WARN "),
-	slog.LevelError: color.New(color.BgRed, color.FgHiWhite).Sprint("ERROR"),
-}
+// DefaultLevelTags are the default level tags, i.e. the level tags of [ThemeDefault].
+var DefaultLevelTags = ThemeDefault.levelTags()
+
+// DefaultTrueColorLevelTags are the default level tags used when [Options.TrueColor] is enabled, i.e. the level tags of [ThemeTrueColor].
+var DefaultTrueColorLevelTags = ThemeTrueColor.levelTags()
 
 // DefaultOptions are the default options.
 var DefaultOptions *Options = &Options{
-	Level:         slog.LevelInfo,
-	TimeFormat:    time.DateTime,
-	SrcFileMode:   MediumFile,
-	SrcFileLength: 0,
-	SrcFileColor:  color.New(),
-	MsgPrefix:     color.HiWhiteString("| "),
-	MsgLength:     0,
-	MsgColor:      color.New(),
-	NoColor:       false,
-	NoTime:        false,
-	LevelTags:     DefaultLevelTags,
+	Level:                slog.LevelInfo,
+	TimeFormat:           time.DateTime,
+	TimePrecision:        TimePrecisionLayout,
+	TimeLocation:         nil,
+	UTC:                  false,
+	ShowGoroutineID:      false,
+	GoroutineIDColor:     nil,
+	SrcFileMode:          MediumFile,
+	SrcFileLength:        0,
+	SrcFileColor:         nil,
+	SrcFuncMode:          Nop,
+	SrcMinLevel:          nil,
+	SrcPosition:          SrcPositionAfterLevel,
+	SrcFuncColor:         nil,
+	SourceColor:          nil,
+	PrefixColor:          nil,
+	PrefixSeparator:      " ",
+	MsgPrefix:            sprint(color.New(color.FgHiWhite), "| "),
+	MsgPrefixColor:       nil,
+	MsgLength:            0,
+	MsgColor:             nil,
+	Format:               FormatColor,
+	NoColor:              false,
+	ForceColor:           false,
+	NoTime:               false,
+	SkipWindowsInit:      false,
+	OmitFields:           0,
+	FieldSeparator:       " ",
+	ColumnSeparator:      " ",
+	SeparatorColor:       nil,
+	AttrIndent:           " ",
+	LineTerminator:       "\n",
+	NoLineTerminator:     false,
+	AlignKeys:            false,
+	KeyColor:             nil,
+	ValueColor:           nil,
+	StringColor:          nil,
+	NumberColor:          nil,
+	BoolColor:            nil,
+	NullColor:            nil,
+	TimeValueColor:       nil,
+	RawTimeValues:        false,
+	FormatDuration:       false,
+	FormatBytes:          false,
+	HighlightJSON:        false,
+	HighlightKeys:        nil,
+	HighlightKeyPatterns: nil,
+	ErrorColor:           nil,
+	ShowErrorType:        false,
+	UnwrapErrors:         false,
+	StackTraceKey:        "",
+	MaxLineWidth:         0,
+	Width:                0,
+	QuoteValues:          false,
+	EscapeNewlines:       true,
+	MaxAttrValueLen:      0,
+	MaxMessageLength:     0,
+	EllipsisStyle:        "…",
+	SampleRate:           nil,
+	SampleWindow:         0,
+	BufferSize:           0,
+	FlushInterval:        0,
+	FlushEachRecord:      false,
+	DedupeKeys:           false,
+	SortAttrs:            false,
+	AttrOrder:            nil,
+	RedactKeys:           nil,
+	RedactKeyPatterns:    nil,
+	GroupStyle:           GroupFlat,
+	Layout:               LayoutCompact,
+	Multiline:            false,
+	MultilineIndent:      "    ",
+	MultilineSeparator:   "",
+	ContextExtractor:     nil,
+	AfterHandle:          nil,
+	AsyncHook:            false,
+	HookWorkers:          0,
+	LevelTags:            nil,
+	LevelIcons:           nil,
+	IconMode:             IconReplace,
+	TrueColor:            false,
+	ColorProfile:         ColorProfileAuto,
+	Theme:                nil,
 }
 
 // Options represents the options passed into [NewHandler].
@@ -35,35 +109,409 @@ type Options struct {
 	// Level reports the minimum level to log.
 	// Levels with lower levels are discarded.
 	// If nil, the Handler uses [slog.LevelInfo].
+	// The level is checked for every record, so a [*slog.LevelVar] can be used to change it at runtime,
+	// also with [Handler.SetLevel].
 	Level slog.Leveler
 
-	// TimeFormat is the time format.
+	// TimeFormat is the time format, either a layout for [time.Time.Format] or one of the TimeFormat constants
+	// such as [TimeFormatUnix], default: time.DateTime.
 	TimeFormat string
 
+	// TimePrecision sets the fractional seconds of the timestamp, replacing those in TimeFormat,
+	// default: TimePrecisionLayout (keep TimeFormat as is). It also applies to TimeFormatRelative.
+	TimePrecision TimePrecision
+
+	// TimeLocation converts the timestamp to this location before it is formatted, default: nil (use the time as provided).
+	TimeLocation *time.Location
+
+	// UTC converts the timestamp to UTC before it is formatted, default: false. Ignored if TimeLocation is set.
+	UTC bool
+
+	// ShowGoroutineID prints the ID of the logging goroutine as 6 zero-padded hex digits between the timestamp
+	// and the level, default: false. The ID is parsed from [runtime.Stack], which makes a record about ten times slower.
+	ShowGoroutineID bool
+
+	// GoroutineIDColor is the color of the goroutine ID, default: nil (use the color of the timestamp of the theme).
+	GoroutineIDColor *color.Color
+
 	// SrcFileMode is the source file mode.
 	SrcFileMode SourceFileMode
 
+	// SrcBaseDir is the project root that MediumFile paths are relative to, default: "" (use the working directory).
+	// Files outside of SrcBaseDir are shown as with ShortFile.
+	SrcBaseDir string
+
 	// SrcFileLength to show fixed length filename to line up the log output, default 0 shows complete filename.
 	SrcFileLength int
 
-	// SrcFileColor is the color of the source file info, default to empty (no color).
+	// SrcFileColor is the color of the source file info, default: nil (use the color of the theme).
 	SrcFileColor *color.Color
 
+	// SrcShowFunction appends the calling function to the source file info (for example main.go:69 (main.run)), default: false.
+	SrcShowFunction bool
+
+	// SrcFormatter formats the source file info, default: nil.
+	// If set, it takes precedence over SrcFileMode, SrcFileLength, SrcHyperlink and SrcFuncMode,
+	// and the returned string is printed as is. An empty string omits the source file info.
+	SrcFormatter func(src *slog.Source) string
+
+	// SrcHyperlink makes the source file info a clickable OSC 8 hyperlink to the absolute path of the file and the line
+	// in terminals that support it, default: false. The text of the link is the file as shown with SrcFileMode.
+	// Only used if color is enabled.
+	SrcHyperlink bool
+
+	// SrcFuncMode shows the calling function before the source file info, either [FuncName] or [FuncShortName], default: Nop.
+	SrcFuncMode SourceFileMode
+
+	// SrcFuncColor is the color of the calling function, default: nil (use the color of the theme).
+	SrcFuncColor *color.Color
+
+	// SourceColor is the color of the whole source field, the calling function and the source file info,
+	// default: nil (use SrcFileColor and SrcFuncColor). SrcFileColor and SrcFuncColor take precedence if they are set.
+	SourceColor *color.Color
+
+	// SrcMinLevel is the minimum level of the records whose source is shown, for example [slog.LevelWarn],
+	// default: nil (all levels). The source of records below it is not resolved at all.
+	SrcMinLevel slog.Leveler
+
+	// SrcPosition is the position of the source, after the level or at the end of the line,
+	// default: SrcPositionAfterLevel.
+	SrcPosition SrcPosition
+
+	// PrefixColor is the color of the prefix of [Handler.WithPrefix], default: nil (use the color of the theme).
+	PrefixColor *color.Color
+
+	// PrefixSeparator separates the prefixes of repeated [Handler.WithPrefix] calls, default: " ".
+	PrefixSeparator string
+
 	// MsgPrefix to show prefix before message, default: white colored "| ".
 	MsgPrefix string
 
-	// MsgColor is the color of the message, default to empty.
+	// MsgPrefixColor is the color of MsgPrefix, for example to print a tag like "[api] " in its own color,
+	// default: nil (print MsgPrefix as is, which may contain its own colors).
+	MsgPrefixColor *color.Color
+
+	// MsgColor is the color of the message, default: nil (use the color of the theme).
 	MsgColor *color.Color
 
 	// MsgLength to show fixed length message to line up the log output, default 0 shows complete message.
+	// Longer messages are truncated with an ellipsis and shorter ones padded with spaces, by terminal columns.
+	// Messages of records without attributes are printed complete.
 	MsgLength int
 
+	// Format is the output format, see [FormatColor], [FormatLogfmt] and [FormatText], default: FormatColor.
+	// The formats other than FormatColor imply NoColor.
+	Format Format
+
 	// NoColor disables color, default: false.
+	// Color is also disabled if the NO_COLOR environment variable is set to a non-empty value,
+	// or if the output is not a terminal (e.g. a file, a pipe or a [bytes.Buffer]) unless ForceColor is set.
+	// Both are checked by [NewHandler].
 	NoColor bool
 
+	// ForceColor enables color even if the output is not a terminal, e.g. when piping into less -R, default: false.
+	// NoColor and the NO_COLOR environment variable take precedence.
+	ForceColor bool
+
 	// NoTime disables time, default: false.
 	NoTime bool
 
-	// LevelTags is level tag for message, default: DefaultLevelStyles
+	// SkipWindowsInit disables enabling the processing of ANSI escape sequences, as with [EnableWindowsANSI],
+	// on Windows if the handler writes to a console, default: false. If it cannot be enabled on an older console,
+	// colors are disabled unless ForceColor is set.
+	SkipWindowsInit bool
+
+	// OmitFields leaves the given built-in fields out of the output, for example OmitTime|OmitSource, default: 0.
+	OmitFields OmitField
+
+	// LevelTags overrides the level tag for the given levels, default: nil (use the level colors of the theme).
 	LevelTags map[slog.Level]string
+
+	// LevelColors overrides the color of the level tag for the given levels, default: nil (use the level colors of the theme).
+	// A tag set explicitly in LevelTags takes precedence over its color here.
+	// Levels without a color use the color of the nearest lower level of the theme.
+	// Use [RGBColor] for 24-bit colors.
+	LevelColors map[slog.Level]*color.Color
+
+	// LevelLabels overrides the text of the level tag for the given levels, for example {slog.Level(2): "NOTICE"},
+	// default: nil (use [slog.Level.String]).
+	LevelLabels map[slog.Level]string
+
+	// CustomLevels defines the labels and colors of custom levels, in any order, default: nil.
+	// Entries in LevelLabels and LevelColors take precedence.
+	CustomLevels []LevelDef
+
+	// LevelWidth pads or truncates the level labels to a fixed width, default 0 pads them to 5 characters without truncating.
+	LevelWidth int
+
+	// PadLevelText pads the level labels to the width of the longest label of the levels in the theme,
+	// LevelColors and LevelLabels instead of 5 characters, default: false. Ignored if LevelWidth is set.
+	PadLevelText bool
+
+	// LevelIcons sets an icon for the given levels, for example {slog.LevelError: "🔥"}, default: nil (text only).
+	// The level labels are padded to the width of the longest one, counting wide characters like emoji as two columns.
+	LevelIcons map[slog.Level]string
+
+	// IconMode is the way the icons of LevelIcons are combined with the level labels, default: IconReplace.
+	IconMode IconMode
+
+	// TrueColor uses the 24-bit [ThemeTrueColor] as the default theme, default: false.
+	// It is enabled automatically if the ColorProfile is ColorProfileTrueColor.
+	TrueColor bool
+
+	// ColorProfile is the set of colors supported by the terminal, default: ColorProfileAuto (ColorProfileTrueColor
+	// if TrueColor is set, otherwise detect it from the COLORTERM and TERM environment variables, where TERM=dumb
+	// disables colors unless ForceColor is set). Colors the profile does not support, like the 24-bit colors
+	// of a theme in a terminal with 16 colors, are replaced with the closest supported ones.
+	ColorProfile ColorProfile
+
+	// Theme is the color theme, default: nil (use [ThemeDefault], or [ThemeTrueColor] if TrueColor is enabled).
+	Theme *Theme
+
+	// FieldSeparator is written between attributes, default: " ".
+	FieldSeparator string
+
+	// ColumnSeparator is written between the time, goroutine ID, level, source and message, for example " | "
+	// for output which is easier to split into columns, default: " ".
+	ColumnSeparator string
+
+	// SeparatorColor is the color of ColumnSeparator, default: nil (no color).
+	SeparatorColor *color.Color
+
+	// AttrIndent is written between the message and the first attribute, for example "   " to set the attributes
+	// further apart from a message padded with MsgLength, default: " ".
+	AttrIndent string
+
+	// LineTerminator is written once at the end of every record, for example "\r\n" for some transports,
+	// default: "\n". The continuation lines of a record, for example of stack traces, still end with "\n".
+	LineTerminator string
+
+	// NoLineTerminator writes the records without LineTerminator, for writers which frame the records themselves,
+	// default: false.
+	NoLineTerminator bool
+
+	// AlignKeys pads the attribute keys of a record to the same width, so that the "=" signs line up, default: false.
+	// This costs an additional pass over the attributes of every record.
+	AlignKeys bool
+
+	// KeyColor is the color of attribute keys, default: nil (use the color of the theme).
+	KeyColor *color.Color
+
+	// ValueColor is the color of attribute values without a more specific color, default: nil (use the color of the theme).
+	ValueColor *color.Color
+
+	// StringColor is the color of string values, default: nil (use the color of the theme, or ValueColor).
+	StringColor *color.Color
+
+	// NumberColor is the color of int, uint and float values, default: nil (use the color of the theme, or ValueColor).
+	NumberColor *color.Color
+
+	// BoolColor is the color of bool values, default: nil (use the color of the theme, or ValueColor).
+	BoolColor *color.Color
+
+	// NullColor is the color of nil values, default: nil (use the color of the theme, or ValueColor).
+	NullColor *color.Color
+
+	// TimeValueColor is the color of time values (not of the timestamp), default: nil (use the color of the theme, or ValueColor).
+	TimeValueColor *color.Color
+
+	// RawTimeValues prints time values with [time.Time.String] and durations as integer nanoseconds, default: false
+	// (print time values like the timestamp, with TimeFormat and TimeLocation, and durations like 1.5s).
+	RawTimeValues bool
+
+	// FormatDuration prints durations in the largest fitting unit with one decimal, for example 850ns, 12.3ms
+	// or 1m2.3s, instead of exactly like 1.234567891s, default: false. It takes precedence over RawTimeValues.
+	FormatDuration bool
+
+	// FormatBytes prints int and uint values whose key ends in "bytes", for example "size_bytes", in binary units
+	// like 1.2 MiB, default: false.
+	FormatBytes bool
+
+	// HighlightJSON colors the keys, strings, numbers and literals of string values that are JSON objects or arrays,
+	// default: false. Other strings, including invalid JSON, are printed as usual.
+	HighlightJSON bool
+
+	// HighlightKeys colors the values of the attributes with the given group-qualified keys, for example
+	// {"request_id": color.New(color.FgHiYellow)}, instead of the colors of their kind, default: nil.
+	HighlightKeys map[string]*color.Color
+
+	// HighlightKeyPatterns colors the values of the attributes whose group-qualified keys match a pattern,
+	// like HighlightKeys, default: nil. HighlightKeys takes precedence, and if several patterns match a key,
+	// the first of them in the order of their source text is used.
+	HighlightKeyPatterns map[*regexp.Regexp]*color.Color
+
+	// ErrorColor is the color of attribute values that are errors, regardless of the level,
+	// default: nil (use the color of the theme).
+	ErrorColor *color.Color
+
+	// ShowErrorType prints the type of error values in parentheses (for example err=EOF (*errors.errorString)), default: false.
+	ShowErrorType bool
+
+	// UnwrapErrors prints the errors wrapped by an error value beneath the log line, one per line
+	// (for example → caused by: EOF), up to a depth of 10, default: false. If the innermost error
+	// implements [StackTracer], the top three frames of its stack trace are printed beneath the chain.
+	UnwrapErrors bool
+
+	// StackTraceKey enables stack traces for attributes with this key: if the value is a []uintptr
+	// or implements [StackTracer], its frames are printed one per line beneath the log line, default: "" (disabled).
+	// Errors carrying a stack trace are still printed inline as well.
+	StackTraceKey string
+
+	// StackTraceExtractor returns the stack trace carried by an attribute, or nil if there is none,
+	// for example to support the stack traces of github.com/pkg/errors, default: nil (disabled).
+	StackTraceExtractor func(a slog.Attr) []uintptr
+
+	// MaxLineWidth limits the log line to this many visible characters, escape sequences not counted, default: 0 (no limit).
+	// If the line is too long, the source is dropped first, then the line is truncated, cutting the attributes
+	// before the message, and EllipsisStyle is appended. Stack traces beneath the line are not limited.
+	MaxLineWidth int
+
+	// Width wraps the attributes of long records: once a line is longer than this many visible characters,
+	// the remaining attributes continue on an indented line beneath the message, default: 0 (the width of the
+	// terminal when the handler is created, or no wrapping if the output is not a terminal). A negative width
+	// disables wrapping. Only applies to the flat GroupStyle, and not if MaxLineWidth is set or Format is logfmt.
+	Width int
+
+	// QuoteValues quotes attribute values containing whitespace, '=', '"' or non-printable characters like logfmt,
+	// for example k="hello world", default: false.
+	QuoteValues bool
+
+	// EscapeNewlines replaces line breaks in the message and attribute values with \n and \r,
+	// so that every record stays on a single line, default: true. Disable it to print multi-line values as is.
+	EscapeNewlines bool
+
+	// MaxAttrValueLen truncates attribute values to this many characters after they are formatted,
+	// appending EllipsisStyle, default: 0 (no limit). The limit is in characters rather than bytes, like MaxLineWidth,
+	// so that values are never cut within a character and the limit relates to the width in the terminal.
+	// A value is at most 4 bytes per character long.
+	MaxAttrValueLen int
+
+	// MaxMessageLength truncates the message to this many characters, appending EllipsisStyle,
+	// default: 0 (no limit). Like MaxAttrValueLen, it counts characters rather than bytes, and escape sequences
+	// in the message are not counted.
+	MaxMessageLength int
+
+	// EllipsisStyle marks a line, value or message truncated because of MaxLineWidth, MaxAttrValueLen
+	// or MaxMessageLength, default: "…".
+	EllipsisStyle string
+
+	// SampleRate writes only the first of every N records with the same message at the given levels, for example
+	// {slog.LevelDebug: 100}, default: nil (write all records). The counts are kept per handler and are not shared
+	// with the handlers returned by WithAttrs and WithGroup. Messages should be constant, with the variable parts
+	// in attributes: at most 10000 different messages are counted, all counts are reset when there are more.
+	SampleRate map[slog.Level]int
+
+	// SampleWindow resets the counts of SampleRate periodically, default: 0 (never).
+	SampleWindow time.Duration
+
+	// BufferSize buffers up to this many bytes of output, so that several records are written at once,
+	// default: 0 (every record is written immediately). The buffer is flushed every FlushInterval,
+	// when it is full, and by [Handler.Flush] and [Handler.Close], which should be called before the program exits.
+	BufferSize int
+
+	// FlushInterval is the interval in which the buffer of BufferSize is flushed, default: 0 (one second).
+	FlushInterval time.Duration
+
+	// FlushEachRecord flushes the output after every record, default: false. If the writer has a Flush() error
+	// method, like [bufio.Writer], it is called, else a Sync() error method, like that of [os.File].
+	// The buffer of BufferSize is flushed too. An error is returned by Handle like a write error,
+	// except the error of syncing a file which does not support it, like a terminal.
+	FlushEachRecord bool
+
+	// DedupeKeys prints only the last of the attributes with the same group-qualified key in a record, for example
+	// the id passed to Info instead of the id added with WithAttrs, default: false. It costs allocations per record.
+	DedupeKeys bool
+
+	// SortAttrs sorts the attributes of each record, including those added with WithAttrs, by key, default: false.
+	// Groups are sorted by name among the keys of their enclosing group, and their members within the group.
+	// Sorting costs an allocation and a sort per record.
+	SortAttrs bool
+
+	// AttrOrder lists keys whose attributes are printed first, in this order, followed by the others sorted by key
+	// as with SortAttrs, default: nil. A key is group-qualified, for example "http.status", and the name of a group
+	// matches all of its members.
+	AttrOrder []string
+
+	// RedactKeys lists keys whose attribute values are replaced with "[REDACTED]", also for the attributes
+	// added with WithAttrs, default: nil. A key is group-qualified, for example "user.password", and matched
+	// case-insensitively. The values are replaced after ReplaceAttr.
+	RedactKeys []string
+
+	// RedactKeyPatterns redacts the values of the attributes whose group-qualified keys match any of the patterns,
+	// like RedactKeys, for dynamic keys like "user.123.token", default: nil.
+	// The patterns are case-sensitive unless they start with (?i).
+	RedactKeyPatterns []*regexp.Regexp
+
+	// GroupStyle is the way attributes in groups are rendered, default: GroupFlat.
+	GroupStyle GroupStyle
+
+	// Layout is the arrangement of the attributes, default: LayoutCompact (on the line of the message).
+	// LayoutExpanded prints each attribute on its own line beneath the message. It does not apply to logfmt.
+	Layout Layout
+
+	// Multiline prints each attribute on its own indented line beneath the message as "key = value",
+	// with the keys of the attributes in the same group padded to align the "=", default: false.
+	// Every group is a line with its name, and its attributes are indented by one more level.
+	// It takes precedence over Layout and GroupStyle, and does not apply to logfmt.
+	Multiline bool
+
+	// MultilineIndent is the indentation of a level with Multiline, default: "    ".
+	MultilineIndent string
+
+	// MultilineSeparator is written on a line of its own after every record with Multiline, for example "─────",
+	// default: "" (none).
+	MultilineSeparator string
+
+	// ContextExtractor returns attributes taken from the context passed to Handle, for example a request ID,
+	// which are printed after the attributes added with WithAttrs and before those of the record, default: nil.
+	// They are not nested in the groups of WithGroup. It is called for every record which is written.
+	ContextExtractor func(ctx context.Context) []slog.Attr
+
+	// AfterHandle is called after every record is written, with the error of the write, for example to count
+	// the records by level, default: nil. It is not called for records dropped by SampleRate. It is called
+	// in the goroutine calling Handle, unless AsyncHook is set.
+	AfterHandle func(r slog.Record, err error)
+
+	// AsyncHook calls AfterHandle in HookWorkers goroutines, so that a slow hook does not block logging,
+	// default: false. Up to 1024 calls are queued, further calls are dropped until the workers catch up.
+	// [Handler.Close] waits for the queued calls.
+	AsyncHook bool
+
+	// HookWorkers is the number of goroutines calling AfterHandle with AsyncHook, default: 0 (one).
+	HookWorkers int
+
+	// ReplaceAttr is called to rewrite each non-group attribute before it is logged,
+	// including attributes added with [Handler.WithAttrs]. The groups argument holds
+	// the groups the attribute is nested in. If the returned attribute has an empty key,
+	// it is dropped.
+	//
+	// The built-in attributes with the keys [slog.TimeKey], [slog.LevelKey], [slog.SourceKey] and [slog.MessageKey]
+	// are passed too, with nil groups, unless they are not shown (e.g. time with NoTime or source with Nop).
+	// A level value that is not a [slog.Level] or a source value that is not a [*slog.Source] is printed as is.
+	// See [slog.HandlerOptions.ReplaceAttr] for details.
+	ReplaceAttr func(groups []string, a slog.Attr) slog.Attr
+}
+
+// Clone returns a deep copy of o, with copies of its maps, slices and Theme, so that changing one does not affect
+// the other. The colors and functions are shared, as colors must not be modified after they are used.
+// [NewHandler] clones its options, so the options can be changed and reused after the handler is created.
+func (o *Options) Clone() *Options {
+	c := *o
+	c.LevelTags = maps.Clone(o.LevelTags)
+	c.LevelColors = maps.Clone(o.LevelColors)
+	c.LevelLabels = maps.Clone(o.LevelLabels)
+	c.LevelIcons = maps.Clone(o.LevelIcons)
+	c.CustomLevels = slices.Clone(o.CustomLevels)
+	c.HighlightKeys = maps.Clone(o.HighlightKeys)
+	c.HighlightKeyPatterns = maps.Clone(o.HighlightKeyPatterns)
+	c.SampleRate = maps.Clone(o.SampleRate)
+	c.AttrOrder = slices.Clone(o.AttrOrder)
+	c.RedactKeys = slices.Clone(o.RedactKeys)
+	c.RedactKeyPatterns = slices.Clone(o.RedactKeyPatterns)
+	if o.Theme != nil {
+		theme := *o.Theme
+		theme.Levels = maps.Clone(o.Theme.Levels)
+		c.Theme = &theme
+	}
+	return &c
 }
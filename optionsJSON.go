@@ -0,0 +1,265 @@
+package slogcolor
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// The types of the options which are converted by [Options.MarshalJSON] and [Options.UnmarshalJSON].
+var (
+	colorType    = reflect.TypeFor[*color.Color]()
+	levelerType  = reflect.TypeFor[slog.Leveler]()
+	locationType = reflect.TypeFor[*time.Location]()
+	durationType = reflect.TypeFor[time.Duration]()
+	regexpType   = reflect.TypeFor[*regexp.Regexp]()
+)
+
+// MarshalJSON implements json.Marshaler, for storing the options in a configuration file.
+// The object has the exported fields of Options as keys, and the values have the following types:
+//
+//   - Colors are strings of SGR parameters, for example "1;31" for bold red, see [Colorize].
+//   - Level and SrcMinLevel are level names like "INFO" or "DEBUG-4". A [*slog.LevelVar] is stored as its current level.
+//   - TimeLocation is the name of the location, for example "Europe/Berlin".
+//   - Durations are strings like "1m30s", see [time.ParseDuration].
+//   - Regular expressions are their source text, see [regexp.Compile].
+//   - Maps with level or regular expression keys have level names or the source texts as keys.
+//   - The enumerations like Format and SrcFileMode and OmitFields are their integer values.
+//
+// Functions like ReplaceAttr and SrcFormatter cannot be stored, they are skipped.
+func (o *Options) MarshalJSON() ([]byte, error) {
+	return json.Marshal(encodeStruct(reflect.ValueOf(o).Elem()))
+}
+
+// UnmarshalJSON implements json.Unmarshaler, reading the options written by [Options.MarshalJSON].
+// Options missing in the object are not changed, and functions like ReplaceAttr are ignored.
+// Unknown keys are an error, to catch typos in configuration files.
+func (o *Options) UnmarshalJSON(data []byte) error {
+	if err := decodeStruct(reflect.ValueOf(o).Elem(), data); err != nil {
+		return fmt.Errorf("slogcolor: %w", err)
+	}
+	return nil
+}
+
+// LoadOptions returns [DefaultOptions] changed by the JSON object in the file at path, see [Options.UnmarshalJSON].
+func LoadOptions(path string) (*Options, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("slogcolor: %w", err)
+	}
+	opts := DefaultOptions.Clone()
+	if err := decodeStruct(reflect.ValueOf(opts).Elem(), data); err != nil {
+		return nil, fmt.Errorf("slogcolor: %s: %w", path, err)
+	}
+	return opts, nil
+}
+
+// encodeStruct returns the exported fields of the struct v without the functions, encoded with encodeValue.
+func encodeStruct(v reflect.Value) map[string]any {
+	m := make(map[string]any, v.NumField())
+	for i := range v.NumField() {
+		f := v.Type().Field(i)
+		if f.IsExported() && f.Type.Kind() != reflect.Func {
+			m[f.Name] = encodeValue(v.Field(i))
+		}
+	}
+	return m
+}
+
+// encodeValue returns v converted to a value which is marshaled as described in [Options.MarshalJSON].
+func encodeValue(v reflect.Value) any {
+	switch v.Type() {
+	case colorType:
+		if v.IsNil() {
+			return nil
+		}
+		start, _ := colorSequences(v.Interface().(*color.Color))
+		return strings.TrimSuffix(strings.TrimPrefix(start, "\x1b["), "m")
+	case levelerType:
+		if v.IsNil() {
+			return nil
+		}
+		return v.Interface().(slog.Leveler).Level().String()
+	case locationType:
+		if v.IsNil() {
+			return nil
+		}
+		return v.Interface().(*time.Location).String()
+	case durationType:
+		return v.Interface().(time.Duration).String()
+	case regexpType:
+		if v.IsNil() {
+			return nil
+		}
+		return v.Interface().(*regexp.Regexp).String()
+	}
+
+	switch v.Kind() {
+	case reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+		m := make(map[string]any, v.Len())
+		for it := v.MapRange(); it.Next(); {
+			key := it.Key().String()
+			if tm, ok := it.Key().Interface().(encoding.TextMarshaler); ok {
+				text, _ := tm.MarshalText()
+				key = string(text)
+			}
+			m[key] = encodeValue(it.Value())
+		}
+		return m
+	case reflect.Slice:
+		if v.IsNil() {
+			return nil
+		}
+		s := make([]any, v.Len())
+		for i := range s {
+			s[i] = encodeValue(v.Index(i))
+		}
+		return s
+	case reflect.Pointer:
+		if v.IsNil() {
+			return nil
+		}
+		return encodeValue(v.Elem())
+	case reflect.Struct:
+		return encodeStruct(v)
+	}
+	return v.Interface()
+}
+
+// decodeStruct sets the exported fields of the struct v to the values in the JSON object data.
+func decodeStruct(v reflect.Value, data []byte) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	for name, raw := range fields {
+		f, ok := v.Type().FieldByName(name)
+		if !ok || !f.IsExported() {
+			return fmt.Errorf("unknown option %q", name)
+		}
+		if f.Type.Kind() == reflect.Func {
+			continue
+		}
+		if err := decodeValue(v.FieldByIndex(f.Index), raw); err != nil {
+			return fmt.Errorf("invalid %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// decodeValue sets v to the JSON value data, which is encoded as described in [Options.MarshalJSON].
+func decodeValue(v reflect.Value, data []byte) error {
+	if string(data) == "null" {
+		v.SetZero()
+		return nil
+	}
+
+	switch v.Type() {
+	case colorType, levelerType, locationType, durationType, regexpType:
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		x, err := parseOptionString(v.Type(), s)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(x))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Map:
+		var entries map[string]json.RawMessage
+		if err := json.Unmarshal(data, &entries); err != nil {
+			return err
+		}
+		m := reflect.MakeMapWithSize(v.Type(), len(entries))
+		for k, raw := range entries {
+			key := reflect.New(v.Type().Key())
+			if v.Type().Key() == regexpType {
+				re, err := regexp.Compile(k)
+				if err != nil {
+					return err
+				}
+				key.Elem().Set(reflect.ValueOf(re))
+			} else if tu, ok := key.Interface().(encoding.TextUnmarshaler); ok {
+				if err := tu.UnmarshalText([]byte(k)); err != nil {
+					return err
+				}
+			} else {
+				key.Elem().SetString(k)
+			}
+			elem := reflect.New(v.Type().Elem()).Elem()
+			if err := decodeValue(elem, raw); err != nil {
+				return fmt.Errorf("%s: %w", k, err)
+			}
+			m.SetMapIndex(key.Elem(), elem)
+		}
+		v.Set(m)
+		return nil
+	case reflect.Slice:
+		var elems []json.RawMessage
+		if err := json.Unmarshal(data, &elems); err != nil {
+			return err
+		}
+		s := reflect.MakeSlice(v.Type(), len(elems), len(elems))
+		for i, raw := range elems {
+			if err := decodeValue(s.Index(i), raw); err != nil {
+				return err
+			}
+		}
+		v.Set(s)
+		return nil
+	case reflect.Pointer:
+		p := reflect.New(v.Type().Elem())
+		if err := decodeValue(p.Elem(), data); err != nil {
+			return err
+		}
+		v.Set(p)
+		return nil
+	case reflect.Struct:
+		return decodeStruct(v, data)
+	}
+	return json.Unmarshal(data, v.Addr().Interface())
+}
+
+// parseOptionString returns the value of type t, which is one of the types converted to strings, encoded in s.
+func parseOptionString(t reflect.Type, s string) (any, error) {
+	switch t {
+	case colorType:
+		c := color.New()
+		if s == "" {
+			return c, nil
+		}
+		for _, p := range strings.Split(s, ";") {
+			n, err := strconv.Atoi(p)
+			if err != nil {
+				return nil, fmt.Errorf("color %q: want SGR parameters like \"1;31\"", s)
+			}
+			c.Add(color.Attribute(n))
+		}
+		return c, nil
+	case levelerType:
+		var l slog.Level
+		err := l.UnmarshalText([]byte(s))
+		return l, err
+	case locationType:
+		return time.LoadLocation(s)
+	case regexpType:
+		return regexp.Compile(s)
+	}
+	return time.ParseDuration(s)
+}
@@ -0,0 +1,164 @@
+package slogcolor_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/geomyidia/slogcolor"
+)
+
+func TestOptionsClone(t *testing.T) {
+	opts := &slogcolor.Options{
+		LevelLabels: map[slog.Level]string{slog.LevelInfo: "INF"},
+		AttrOrder:   []string{"a"},
+		Theme:       &slogcolor.Theme{Levels: map[slog.Level]*color.Color{slog.LevelInfo: color.New(color.FgGreen)}},
+	}
+	c := opts.Clone()
+	c.LevelLabels[slog.LevelInfo] = "I"
+	c.AttrOrder[0] = "b"
+	c.Theme.Levels[slog.LevelInfo] = nil
+
+	if opts.LevelLabels[slog.LevelInfo] != "INF" || opts.AttrOrder[0] != "a" || opts.Theme.Levels[slog.LevelInfo] == nil {
+		t.Errorf("changing the clone changed the options: %+v", opts)
+	}
+}
+
+// TestOptionsMutatedConcurrently fails with the race detector if the handlers share the maps of the options.
+func TestOptionsMutatedConcurrently(t *testing.T) {
+	opts := &slogcolor.Options{
+		Level:       slog.LevelInfo,
+		ForceColor:  true,
+		LevelColors: map[slog.Level]*color.Color{slog.LevelWarn: color.New(color.FgYellow)},
+		LevelLabels: map[slog.Level]string{slog.LevelWarn: "WARNING"},
+	}
+	handlers := []*slogcolor.Handler{slogcolor.NewHandler(io.Discard, opts), slogcolor.NewHandler(io.Discard, opts)}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := range 1000 {
+			opts.LevelColors[slog.Level(i)] = color.New(color.FgRed)
+			opts.LevelLabels[slog.Level(i)] = "CUSTOM"
+		}
+	}()
+	for _, h := range handlers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			l := slog.New(h)
+			for i := range 1000 {
+				l.Log(t.Context(), slog.Level(i%16), "msg")
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestOptionsJSON(t *testing.T) {
+	levelTrace := slog.Level(-8)
+	opts := &slogcolor.Options{
+		Level:                levelTrace,
+		TimeFormat:           time.Kitchen,
+		TimeLocation:         time.UTC,
+		SrcFileMode:          slogcolor.ShortFile,
+		MsgPrefix:            "> ",
+		MsgColor:             color.New(color.Bold, color.FgHiWhite),
+		ForceColor:           true,
+		CustomLevels:         []slogcolor.LevelDef{{Level: levelTrace, Name: "TRACE", Color: color.New(color.FgHiBlack)}},
+		LevelLabels:          map[slog.Level]string{slog.LevelWarn: "WARNING"},
+		LevelColors:          map[slog.Level]*color.Color{slog.LevelError: color.New(color.BgHiRed)},
+		Theme:                slogcolor.ThemeDracula,
+		KeyColor:             color.New(),
+		HighlightKeys:        map[string]*color.Color{"id": color.New(color.FgYellow, color.Underline)},
+		EscapeNewlines:       true,
+		FormatDuration:       true,
+		SampleWindow:         time.Minute,
+		AttrOrder:            []string{"id"},
+		RedactKeys:           []string{"password"},
+		RedactKeyPatterns:    []*regexp.Regexp{regexp.MustCompile(`^http\.`)},
+		HighlightKeyPatterns: map[*regexp.Regexp]*color.Color{regexp.MustCompile(`^i`): color.New(color.FgRed)},
+		GroupStyle:           slogcolor.GroupBraces,
+		ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+			return a
+		},
+	}
+	data, err := json.Marshal(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "ReplaceAttr") {
+		t.Errorf("functions were marshaled: %s", data)
+	}
+
+	var got slogcolor.Options
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.ReplaceAttr != nil {
+		t.Error("ReplaceAttr was set")
+	}
+
+	render := func(opts *slogcolor.Options) string {
+		var buf bytes.Buffer
+		l := slog.New(slogcolor.NewHandler(&buf, opts)).With("id", 7)
+		for _, level := range []slog.Level{levelTrace, slog.LevelInfo, slog.LevelWarn, slog.LevelError} {
+			l.Log(t.Context(), level, "msg\nline", "password", "secret", "d", time.Second,
+				slog.Group("http", "status", 200, "ok", true))
+		}
+		return buf.String()
+	}
+	if g, w := render(&got), render(opts); g != w {
+		t.Errorf("unmarshaled options render\n%q\nwant\n%q\njson: %s", g, w, data)
+	}
+}
+
+func TestLoadOptions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log.json")
+	if err := os.WriteFile(path, []byte(`{"Level": "WARN", "NoTime": true, "NoColor": true, "KeyColor": "1;36", "SrcFileMode": 2}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	opts, err := slogcolor.LoadOptions(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.Level != slog.LevelWarn || !opts.NoTime || !opts.KeyColor.Equals(color.New(color.Bold, color.FgCyan)) {
+		t.Errorf("got %+v", opts)
+	}
+	if opts.SrcFileMode != slogcolor.MediumFile {
+		t.Errorf("got SrcFileMode %v, want MediumFile: the values of the modes must not change", opts.SrcFileMode)
+	}
+	if opts.TimeFormat != slogcolor.DefaultOptions.TimeFormat || !opts.EscapeNewlines {
+		t.Errorf("options missing in the file are not the defaults: %+v", opts)
+	}
+
+	for _, tt := range []struct{ json, err string }{
+		{`{"Levle": "WARN"}`, `unknown option "Levle"`},
+		{`{"KeyColor": "red"}`, `invalid KeyColor: color "red"`},
+		{`{"Level": "LOUD"}`, `invalid Level`},
+		{`{"LevelLabels": {"FATAL": "F"}}`, `invalid LevelLabels`},
+		{`{"SampleWindow": 5}`, `invalid SampleWindow`},
+		{`[]`, `cannot unmarshal array`},
+	} {
+		if err := os.WriteFile(path, []byte(tt.json), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := slogcolor.LoadOptions(path); err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: got error %v, want %q", tt.json, err, tt.err)
+		}
+	}
+	if _, err := slogcolor.LoadOptions(filepath.Join(t.TempDir(), "missing.json")); !os.IsNotExist(errors.Unwrap(err)) {
+		t.Errorf("missing file: got error %v", err)
+	}
+}
@@ -0,0 +1,21 @@
+module github.com/geomyidia/slogcolor/otel
+
+go 1.24.4
+
+require (
+	github.com/fatih/color v1.18.0
+	// placeholder for the first release with WithOptions and HighlightKeyPatterns, which is not tagged yet:
+	// the root module must be tagged before this module, see CONTRIBUTING.md
+	github.com/geomyidia/slogcolor v1.9.0
+	go.opentelemetry.io/otel/trace v1.35.0
+)
+
+require (
+	github.com/mattn/go-colorable v0.1.13 // indirect
+	github.com/mattn/go-isatty v0.0.20 // indirect
+	go.opentelemetry.io/otel v1.35.0 // indirect
+	golang.org/x/sys v0.25.0 // indirect
+)
+
+// the module is developed together with slogcolor, consumers use the required release
+replace github.com/geomyidia/slogcolor => ../
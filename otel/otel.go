@@ -0,0 +1,77 @@
+// Package otel adds the trace and span IDs of OpenTelemetry to the records of a slog handler.
+// It is a separate module, so that slogcolor does not depend on OpenTelemetry.
+package otel
+
+import (
+	"context"
+	"log/slog"
+	"regexp"
+
+	"github.com/fatih/color"
+	"github.com/geomyidia/slogcolor"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// The keys of the attributes added by [WithOTelContext].
+const (
+	TraceIDKey = "trace_id"
+	SpanIDKey  = "span_id"
+)
+
+// idColor is the color of the trace and span IDs in the output of a [slogcolor.Handler].
+var idColor = color.New(color.FgMagenta)
+
+// idKeys match the keys of the trace and span IDs, also in the groups of WithGroup.
+var idKeys = regexp.MustCompile(`(^|\.)(` + TraceIDKey + `|` + SpanIDKey + `)$`)
+
+// otelHandler is the handler returned by [WithOTelContext].
+type otelHandler struct {
+	next slog.Handler
+}
+
+// WithOTelContext returns a handler which adds the trace_id and span_id of the span in the context
+// passed to Handle, if there is a valid one, before the attributes of each record and passes it to h.
+// Like the other attributes of the record, they are nested in the groups of WithGroup. If h is a [*slogcolor.Handler],
+// the IDs are colored with a distinct color, also in groups, unless [slogcolor.Options.HighlightKeys] sets a color
+// for their group-qualified keys.
+func WithOTelContext(h slog.Handler) slog.Handler {
+	if sh, ok := h.(*slogcolor.Handler); ok {
+		h = sh.WithOptions(func(o *slogcolor.Options) {
+			if o.HighlightKeyPatterns == nil {
+				o.HighlightKeyPatterns = make(map[*regexp.Regexp]*color.Color, 1)
+			}
+			o.HighlightKeyPatterns[idKeys] = idColor
+		})
+	}
+	return &otelHandler{next: h}
+}
+
+// Enabled implements slog.Handler.Enabled .
+func (h *otelHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+// Handle implements slog.Handler.Handle .
+func (h *otelHandler) Handle(ctx context.Context, r slog.Record) error {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return h.next.Handle(ctx, r)
+	}
+	r2 := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	r2.AddAttrs(slog.String(TraceIDKey, sc.TraceID().String()), slog.String(SpanIDKey, sc.SpanID().String()))
+	r.Attrs(func(a slog.Attr) bool {
+		r2.AddAttrs(a)
+		return true
+	})
+	return h.next.Handle(ctx, r2)
+}
+
+// WithAttrs implements slog.Handler.WithAttrs .
+func (h *otelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &otelHandler{next: h.next.WithAttrs(attrs)}
+}
+
+// WithGroup implements slog.Handler.WithGroup .
+func (h *otelHandler) WithGroup(name string) slog.Handler {
+	return &otelHandler{next: h.next.WithGroup(name)}
+}
@@ -0,0 +1,97 @@
+package otel_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/geomyidia/slogcolor"
+	"github.com/geomyidia/slogcolor/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func spanContext(t *testing.T) context.Context {
+	traceID, err := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	if err != nil {
+		t.Fatal(err)
+	}
+	spanID, err := trace.SpanIDFromHex("00f067aa0ba902b7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sc := trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: spanID, TraceFlags: trace.FlagsSampled})
+	return trace.ContextWithSpanContext(context.Background(), sc)
+}
+
+func TestWithOTelContext(t *testing.T) {
+	var buf bytes.Buffer
+	h := slogcolor.NewHandler(&buf, &slogcolor.Options{Level: slog.LevelInfo, NoColor: true, NoTime: true})
+	l := slog.New(otel.WithOTelContext(h)).With("app", "srv").WithGroup("http")
+
+	l.InfoContext(spanContext(t), "request", "status", 200)
+	l.Info("no span")
+
+	want := "INFO  request app=srv http.trace_id=4bf92f3577b34da6a3ce929d0e0e4736 http.span_id=00f067aa0ba902b7 http.status=200\n" +
+		"INFO  no span app=srv\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestWithOTelContextColor(t *testing.T) {
+	t.Setenv("NO_COLOR", "") // ForceColor does not override NO_COLOR
+	var buf bytes.Buffer
+	h := slogcolor.NewHandler(&buf, &slogcolor.Options{
+		Level:        slog.LevelInfo,
+		ForceColor:   true,
+		ColorProfile: slogcolor.ColorProfileANSI16,
+		NoTime:       true,
+		Theme:        &slogcolor.Theme{},
+	})
+	slog.New(otel.WithOTelContext(h)).InfoContext(spanContext(t), "request")
+
+	want := "INFO  request trace_id=\x1b[35m4bf92f3577b34da6a3ce929d0e0e4736\x1b[0m span_id=\x1b[35m00f067aa0ba902b7\x1b[0m\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestWithOTelContextColorGroup(t *testing.T) {
+	t.Setenv("NO_COLOR", "") // ForceColor does not override NO_COLOR
+	var buf bytes.Buffer
+	h := slogcolor.NewHandler(&buf, &slogcolor.Options{
+		Level:         slog.LevelInfo,
+		ForceColor:    true,
+		ColorProfile:  slogcolor.ColorProfileANSI16,
+		NoTime:        true,
+		Theme:         &slogcolor.Theme{},
+		HighlightKeys: map[string]*color.Color{"http.span_id": color.New(color.FgCyan)},
+	})
+	slog.New(otel.WithOTelContext(h)).WithGroup("http").InfoContext(spanContext(t), "request", "my_trace_id", "x")
+
+	want := "INFO  request http.trace_id=\x1b[35m4bf92f3577b34da6a3ce929d0e0e4736\x1b[0m" +
+		" http.span_id=\x1b[36m00f067aa0ba902b7\x1b[0m http.my_trace_id=x\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestWithOTelContextOtherHandler(t *testing.T) {
+	var buf bytes.Buffer
+	h := slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey && groups == nil {
+				return slog.Attr{}
+			}
+			return a
+		},
+	})
+	slog.New(otel.WithOTelContext(h)).InfoContext(spanContext(t), "request", "k", "v")
+
+	want := "level=INFO msg=request trace_id=4bf92f3577b34da6a3ce929d0e0e4736 span_id=00f067aa0ba902b7 k=v\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,6 @@
+//go:build race
+
+package slogcolor_test
+
+// raceEnabled is true if the tests are run with the race detector, which randomly drops pooled buffers.
+const raceEnabled = true
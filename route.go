@@ -0,0 +1,93 @@
+package slogcolor
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+)
+
+// LevelRoute is an output of [NewRoutedHandler] for the records with levels from MinLevel to MaxLevel, inclusive.
+type LevelRoute struct {
+	MinLevel, MaxLevel slog.Level
+	Writer             io.Writer
+}
+
+// includes reports whether level is in the range of the route.
+func (r LevelRoute) includes(level slog.Level) bool {
+	return level >= r.MinLevel && level <= r.MaxLevel
+}
+
+// routedHandler passes every record to the handlers of the routes which include its level.
+type routedHandler struct {
+	routes   []LevelRoute
+	handlers []slog.Handler
+}
+
+// NewRoutedHandler creates a handler that writes every record to the writers of all routes whose level range
+// includes the level of the record, for example WARN and above to stderr and the rest to stdout.
+// The ranges may overlap, and records not included in any route are dropped. Each route is written by
+// its own [Handler] with the specified options. If opts is nil, uses [DefaultOptions].
+// The handler has the methods Flush and Close of [Handler], which flush and close the handlers of all routes,
+// for example with [Options.BufferSize].
+func NewRoutedHandler(routes []LevelRoute, opts *Options) slog.Handler {
+	h := &routedHandler{routes: routes, handlers: make([]slog.Handler, len(routes))}
+	for i, route := range routes {
+		h.handlers[i] = NewHandler(route.Writer, opts)
+	}
+	return h
+}
+
+// Enabled implements slog.Handler.Enabled .
+func (h *routedHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for i, route := range h.routes {
+		if route.includes(level) && h.handlers[i].Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+// Handle implements slog.Handler.Handle .
+func (h *routedHandler) Handle(ctx context.Context, r slog.Record) error {
+	var err error
+	for i, route := range h.routes {
+		if route.includes(r.Level) && h.handlers[i].Enabled(ctx, r.Level) {
+			if e := h.handlers[i].Handle(ctx, r.Clone()); e != nil {
+				err = errors.Join(err, e)
+			}
+		}
+	}
+	return err
+}
+
+// Flush writes the buffered records of all routes, see [Handler.Flush].
+func (h *routedHandler) Flush() error {
+	return flushHandlers(h.handlers)
+}
+
+// Close implements io.Closer. It closes the handlers of all routes, see [Handler.Close].
+func (h *routedHandler) Close() error {
+	return closeHandlers(h.handlers)
+}
+
+// WithAttrs implements slog.Handler.WithAttrs .
+func (h *routedHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make([]slog.Handler, len(h.handlers))
+	for i, h := range h.handlers {
+		handlers[i] = h.WithAttrs(attrs)
+	}
+	return &routedHandler{routes: h.routes, handlers: handlers}
+}
+
+// WithGroup implements slog.Handler.WithGroup .
+func (h *routedHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	handlers := make([]slog.Handler, len(h.handlers))
+	for i, h := range h.handlers {
+		handlers[i] = h.WithGroup(name)
+	}
+	return &routedHandler{routes: h.routes, handlers: handlers}
+}
@@ -0,0 +1,71 @@
+package slogcolor_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/geomyidia/slogcolor"
+)
+
+func TestNewRoutedHandler(t *testing.T) {
+	var stdout, stderr, all bytes.Buffer
+	h := slogcolor.NewRoutedHandler([]slogcolor.LevelRoute{
+		{MinLevel: slog.LevelDebug, MaxLevel: slog.LevelInfo, Writer: &stdout},
+		{MinLevel: slog.LevelWarn, MaxLevel: slog.LevelError, Writer: &stderr},
+		{MinLevel: slog.LevelInfo, MaxLevel: slog.LevelWarn, Writer: &all},
+	}, &slogcolor.Options{Level: slog.LevelDebug, NoColor: true, NoTime: true})
+	l := slog.New(h).With("app", "srv").WithGroup("req")
+
+	l.Debug("debug")
+	l.Info("info", "i", 1)
+	l.Warn("warn")
+	l.Error("error")
+	l.Log(context.Background(), slog.LevelError+4, "dropped")
+
+	for _, tt := range []struct {
+		name string
+		buf  *bytes.Buffer
+		want string
+	}{
+		{"stdout", &stdout, "DEBUG debug app=srv\nINFO  info app=srv req.i=1\n"},
+		{"stderr", &stderr, "WARN  warn app=srv\nERROR error app=srv\n"},
+		{"overlapping", &all, "INFO  info app=srv req.i=1\nWARN  warn app=srv\n"},
+	} {
+		if got := tt.buf.String(); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	if h.Enabled(context.Background(), slog.LevelError+4) {
+		t.Error("handler enabled for a level without a route")
+	}
+}
+
+func BenchmarkRoutedHandler(b *testing.B) {
+	r := slog.NewRecord(time.Now(), slog.LevelInfo, "benchmarking", 0)
+	r.AddAttrs(slog.Int("i", 42), slog.String("path", "/api/users"))
+	ctx := context.Background()
+	opts := &slogcolor.Options{Level: slog.LevelInfo, ForceColor: true}
+
+	for _, bb := range []struct {
+		name string
+		h    slog.Handler
+	}{
+		{"single", slogcolor.NewHandler(io.Discard, opts)},
+		{"routed", slogcolor.NewRoutedHandler([]slogcolor.LevelRoute{
+			{MinLevel: slog.LevelDebug, MaxLevel: slog.LevelInfo, Writer: io.Discard},
+			{MinLevel: slog.LevelWarn, MaxLevel: slog.LevelError, Writer: io.Discard},
+		}, opts)},
+	} {
+		b.Run(bb.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				bb.h.Handle(ctx, r)
+			}
+		})
+	}
+}
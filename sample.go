@@ -0,0 +1,68 @@
+package slogcolor
+
+import (
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// maxSampleKeys is the number of different messages counted by a sampler, see [Options.SampleRate].
+const maxSampleKeys = 10000
+
+// sampler counts the records with the same level and message, see [Options.SampleRate].
+type sampler struct {
+	counts    sync.Map     // sampleKey → *atomic.Uint64
+	size      atomic.Int64 // the number of keys in counts, approximately under concurrent resets
+	nextReset atomic.Int64 // Unix nanoseconds after which the counts are reset, if there is a SampleWindow
+}
+
+// reset clears the counts.
+func (s *sampler) reset() {
+	s.counts.Clear()
+	s.size.Store(0)
+}
+
+type sampleKey struct {
+	level slog.Level
+	msg   string
+}
+
+// newSampler returns a new sampler if [Options.SampleRate] is set, or nil otherwise.
+func (h *Handler) newSampler() *sampler {
+	if len(h.opts.SampleRate) == 0 {
+		return nil
+	}
+	s := &sampler{}
+	if h.opts.SampleWindow > 0 {
+		s.nextReset.Store(time.Now().Add(h.opts.SampleWindow).UnixNano())
+	}
+	return s
+}
+
+// sample reports whether the record with level and msg should be written.
+// The first of every N identical records is written, where N is the sample rate of the level.
+func (h *Handler) sample(level slog.Level, msg string) bool {
+	n := uint64(max(h.opts.SampleRate[level], 0))
+	if h.sampler == nil || n <= 1 {
+		return true
+	}
+
+	if h.opts.SampleWindow > 0 {
+		now := time.Now().UnixNano()
+		if next := h.sampler.nextReset.Load(); now >= next &&
+			h.sampler.nextReset.CompareAndSwap(next, now+int64(h.opts.SampleWindow)) {
+			h.sampler.reset()
+		}
+	}
+
+	key := sampleKey{level, msg}
+	c, ok := h.sampler.counts.Load(key)
+	if !ok {
+		var loaded bool
+		if c, loaded = h.sampler.counts.LoadOrStore(key, new(atomic.Uint64)); !loaded && h.sampler.size.Add(1) > maxSampleKeys {
+			h.sampler.reset() // messages with variable parts would grow the counts without bound
+		}
+	}
+	return (c.(*atomic.Uint64).Add(1)-1)%n == 0
+}
@@ -0,0 +1,113 @@
+// Package slogcolortest provides a handler for tests which collects the records and writes the output
+// of a [slogcolor.Handler] to the test log if the test failed.
+package slogcolortest
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/geomyidia/slogcolor"
+)
+
+// RecordSink collects the records handled by a handler created with [NewHandler].
+type RecordSink struct {
+	mu      sync.Mutex
+	records []slog.Record
+}
+
+// Records returns a copy of the records handled so far. Attributes added with WithAttrs
+// are included, and attributes are nested in the groups added with WithGroup.
+func (s *RecordSink) Records() []slog.Record {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	records := make([]slog.Record, len(s.records))
+	for i, r := range s.records {
+		records[i] = r.Clone()
+	}
+	return records
+}
+
+func (s *RecordSink) add(r slog.Record) {
+	s.mu.Lock()
+	s.records = append(s.records, r)
+	s.mu.Unlock()
+}
+
+// NewHandler creates a new handler for use in tests. Its output is buffered and written to t.Log
+// at the end of the test, but only if the test failed. The returned [RecordSink] collects
+// every handled record, so that tests can check them without parsing the output. If opts is nil,
+// uses [slogcolor.DefaultOptions].
+func NewHandler(t testing.TB, opts *slogcolor.Options) (slog.Handler, *RecordSink) {
+	t.Helper()
+
+	bf := &bytes.Buffer{}
+	h := &testHandler{h: slogcolor.NewHandler(bf, opts), sink: &RecordSink{}}
+	t.Cleanup(func() {
+		if t.Failed() && bf.Len() > 0 {
+			t.Log("log output:\n" + bf.String())
+		}
+	})
+	return h, h.sink
+}
+
+// testHandler records the records passed to a [slogcolor.Handler].
+type testHandler struct {
+	h      slog.Handler
+	sink   *RecordSink
+	attrs  []slog.Attr
+	groups []string
+}
+
+// Enabled implements slog.Handler.Enabled .
+func (h *testHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.h.Enabled(ctx, level)
+}
+
+// Handle implements slog.Handler.Handle .
+func (h *testHandler) Handle(ctx context.Context, r slog.Record) error {
+	rec := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	rec.AddAttrs(h.attrs...)
+	attrs := make([]slog.Attr, 0, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, a)
+		return true
+	})
+	rec.AddAttrs(h.nest(attrs)...)
+	h.sink.add(rec)
+
+	return h.h.Handle(ctx, r)
+}
+
+// WithAttrs implements slog.Handler.WithAttrs .
+func (h *testHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	h2 := *h
+	h2.h = h.h.WithAttrs(attrs)
+	h2.attrs = append(slices.Clip(h.attrs), h.nest(attrs)...)
+	return &h2
+}
+
+// WithGroup implements slog.Handler.WithGroup .
+func (h *testHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.h = h.h.WithGroup(name)
+	h2.groups = append(slices.Clip(h.groups), name)
+	return &h2
+}
+
+// nest nests attrs in the open groups.
+func (h *testHandler) nest(attrs []slog.Attr) []slog.Attr {
+	if len(attrs) == 0 {
+		return nil
+	}
+	for i := len(h.groups) - 1; i >= 0; i-- {
+		attrs = []slog.Attr{{Key: h.groups[i], Value: slog.GroupValue(attrs...)}}
+	}
+	return attrs
+}
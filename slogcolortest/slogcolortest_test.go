@@ -0,0 +1,38 @@
+package slogcolortest_test
+
+import (
+	"log/slog"
+	"slices"
+	"testing"
+
+	"github.com/geomyidia/slogcolor/slogcolortest"
+)
+
+func TestNewHandler(t *testing.T) {
+	h, sink := slogcolortest.NewHandler(t, nil)
+	l := slog.New(h).With("app", "srv").WithGroup("http")
+	l.Info("request", "status", 200)
+	l.Debug("dropped")
+	l.Warn("slow")
+
+	records := sink.Records()
+	if len(records) != 2 {
+		t.Fatalf("got %d records, want 2", len(records))
+	}
+
+	r := records[0]
+	if r.Level != slog.LevelInfo || r.Message != "request" {
+		t.Errorf("got level %v and message %q", r.Level, r.Message)
+	}
+	var attrs []string
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, a.String())
+		return true
+	})
+	if want := []string{"app=srv", "http=[status=200]"}; !slices.Equal(attrs, want) {
+		t.Errorf("got attrs %v, want %v", attrs, want)
+	}
+	if records[1].Message != "slow" || records[1].NumAttrs() != 1 {
+		t.Errorf("got record %v", records[1])
+	}
+}
@@ -0,0 +1,203 @@
+package slogcolor
+
+import (
+	"bytes"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// callersFrames is [runtime.CallersFrames], replaced in tests.
+var callersFrames = runtime.CallersFrames
+
+// source resolves pc to a [slog.Source]. It returns nil if pc is zero, if no source info is shown
+// or if level is below [Options.SrcMinLevel].
+func (h *Handler) source(pc uintptr, level slog.Level) *slog.Source {
+	if pc == 0 || (h.opts.SrcFileMode == Nop && h.opts.SrcFuncMode == Nop && h.opts.SrcFormatter == nil) {
+		return nil
+	}
+	if h.opts.SrcMinLevel != nil && level < h.opts.SrcMinLevel.Level() {
+		return nil
+	}
+	f, _ := callersFrames([]uintptr{pc}).Next()
+	return &slog.Source{Function: f.Function, File: f.File, Line: f.Line}
+}
+
+// writeSource writes the source info f to bf according to [Options.SrcFileMode] and [Options.SrcFuncMode],
+// or with [Options.SrcFormatter] if it is set.
+func (h *Handler) writeSource(bf *bytes.Buffer, f *slog.Source) {
+	if h.opts.SrcFormatter != nil {
+		if s := h.opts.SrcFormatter(f); s != "" {
+			h.writeColor(bf, h.opts.SrcFileColor, s)
+			bf.WriteString(" ")
+		}
+		return
+	}
+
+	fileMode, funcMode := h.opts.SrcFileMode, h.opts.SrcFuncMode
+	if fileMode == FuncName || fileMode == FuncShortName {
+		fileMode, funcMode = Nop, fileMode
+	}
+
+	frame := runtime.Frame{Function: f.Function, File: f.File, Line: f.Line}
+	if funcMode == FuncName || funcMode == FuncShortName {
+		h.writeColor(bf, h.opts.SrcFuncColor, h.formatSource(frame, funcMode), " ")
+	}
+
+	if fileMode == Nop {
+		return
+	}
+	src := h.formatSource(frame, fileMode)
+	i := strings.LastIndexByte(src, ':')
+	filename, lineStr := src[:i], src[i:]
+	if h.opts.SrcShowFunction && f.Function != "" {
+		lineStr += " (" + pkgFuncName(f.Function) + ")"
+	}
+	formatted := filename + lineStr + " "
+	if h.opts.SrcFileLength > 0 {
+		formatted = fitSource(filename, lineStr, h.opts.SrcFileLength)
+	}
+	if h.opts.SrcHyperlink && !h.opts.NoColor {
+		text := strings.TrimRight(formatted, " ")
+		bf.WriteString(hyperlink(h.sprint(h.opts.SrcFileColor, text), absPath(f.File), f.Line) + formatted[len(text):])
+		return
+	}
+	h.writeColor(bf, h.opts.SrcFileColor, formatted)
+}
+
+// fitSource returns filename and lineStr padded with spaces to width characters, with at least one space at the end.
+// The filename is truncated if they are too long, and lineStr too if it does not fit alone.
+func fitSource(filename, lineStr string, width int) string {
+	avail := max(0, width-1)
+	if n := utf8.RuneCountInString(lineStr); n > avail {
+		filename, lineStr = "", truncateRunes(lineStr, avail)
+	} else {
+		filename = truncateRunes(filename, avail-n)
+	}
+	s := filename + lineStr
+	return s + strings.Repeat(" ", max(0, width-utf8.RuneCountInString(s)))
+}
+
+// truncateRunes returns the first n characters of s.
+func truncateRunes(s string, n int) string {
+	for i := range s {
+		if n == 0 {
+			return s[:i]
+		}
+		n--
+	}
+	return s
+}
+
+// formatSource returns the source info of frame in mode, for example main.go:69 for ShortFile
+// or (*Server).ServeHTTP for FuncShortName. It returns an empty string for Nop.
+func (h *Handler) formatSource(frame runtime.Frame, mode SourceFileMode) string {
+	line := ":" + strconv.Itoa(frame.Line)
+	switch mode {
+	case ShortFile:
+		return filepath.Base(frame.File) + line
+	case PackageFile:
+		return packageFile(frame.File) + line
+	case MediumFile:
+		return h.getRelativePath(frame.File) + line
+	case LongFile:
+		return frame.File + line
+	case FuncName:
+		return frame.Function
+	case FuncShortName:
+		return shortFuncName(frame.Function)
+	case LineOnly:
+		return line
+	}
+	return ""
+}
+
+// normalizeBaseDir returns the absolute form of dir with forward slashes and a trailing slash,
+// so that it can be stripped from the file paths of [runtime.Frame].
+func normalizeBaseDir(dir string) string {
+	if dir == "" {
+		return ""
+	}
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	dir = filepath.ToSlash(filepath.Clean(dir))
+	if !strings.HasSuffix(dir, "/") {
+		dir += "/"
+	}
+	return dir
+}
+
+// absPath returns the absolute form of path, which is relative if the binary was built with -trimpath.
+func absPath(path string) string {
+	if !filepath.IsAbs(path) {
+		if abs, err := filepath.Abs(path); err == nil {
+			return filepath.ToSlash(abs)
+		}
+	}
+	return path
+}
+
+// hyperlink wraps text in an OSC 8 hyperlink to line in file, which is clickable in terminals that support it.
+func hyperlink(text, file string, line int) string {
+	u := url.URL{Scheme: "file", Path: file, Fragment: strconv.Itoa(line)}
+	return "\x1b]8;;" + u.String() + "\x1b\\" + text + "\x1b]8;;\x1b\\"
+}
+
+// pkgFuncName strips the package path but not the package name from a fully qualified function name,
+// for example github.com/foo/bar.(*Server).ServeHTTP becomes bar.(*Server).ServeHTTP.
+func pkgFuncName(name string) string {
+	if i := strings.LastIndexByte(name, '/'); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
+}
+
+// shortFuncName strips the package path from a fully qualified function name,
+// for example github.com/foo/bar.(*Server).ServeHTTP becomes (*Server).ServeHTTP.
+func shortFuncName(name string) string {
+	name = pkgFuncName(name)
+	if i := strings.IndexByte(name, '.'); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
+}
+
+// packageFile returns the last two segments of path, i.e. the package directory and the filename.
+func packageFile(path string) string {
+	// runtime.Frame.File always uses forward slashes
+	i := strings.LastIndexByte(path, '/')
+	if i < 0 {
+		return path
+	}
+	if j := strings.LastIndexByte(path[:i], '/'); j >= 0 {
+		return path[j+1:]
+	}
+	return path
+}
+
+// getRelativePath returns the file path relative to the project root,
+// which is [Options.SrcBaseDir] if it is set or the working directory otherwise.
+func (h *Handler) getRelativePath(fullPath string) string {
+	if h.srcBaseDir != "" {
+		if rel, ok := strings.CutPrefix(fullPath, h.srcBaseDir); ok {
+			return rel
+		}
+		// Fallback to the filename for files outside of the base directory
+		return filepath.Base(fullPath)
+	}
+
+	// Try to get the working directory (project root)
+	if wd, err := os.Getwd(); err == nil {
+		if relPath, err := filepath.Rel(wd, fullPath); err == nil {
+			return relPath
+		}
+	}
+	// Fallback to full path if we can't determine relative path
+	return fullPath
+}
@@ -2,6 +2,7 @@ package slogcolor
 
 type SourceFileMode int
 
+// The values are stored as integers, for example by [Options.MarshalJSON], so new modes are appended.
 const (
 	// Nop does nothing.
 	Nop SourceFileMode = iota
@@ -10,8 +11,23 @@ const (
 	ShortFile
 
 	// MediumFile produces the relative file path from project root (for example cmd/server/main.go:69).
+	// The project root is [Options.SrcBaseDir], or the working directory if it is not set.
 	MediumFile
 
 	// LongFile produces the full file path (for example /home/user/go/src/myapp/main.go:69).
 	LongFile
+
+	// PackageFile produces the package directory and the filename (for example server/main.go:69).
+	PackageFile
+
+	// FuncName produces the fully qualified name of the calling function (for example github.com/foo/bar.(*Server).ServeHTTP).
+	// It can also be used as [Options.SrcFuncMode] to show it alongside the source file.
+	FuncName
+
+	// FuncShortName produces the name of the calling function without the package path (for example (*Server).ServeHTTP).
+	// It can also be used as [Options.SrcFuncMode] to show it alongside the source file.
+	FuncShortName
+
+	// LineOnly produces only the line number (for example :69), for generated code where the filenames are meaningless.
+	LineOnly
 )
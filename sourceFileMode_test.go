@@ -0,0 +1,150 @@
+package slogcolor
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"regexp"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestPackageFile(t *testing.T) {
+	for _, tt := range []struct {
+		path, want string
+	}{
+		{"main.go", "main.go"},
+		{"/main.go", "/main.go"},
+		{"cmd/main.go", "cmd/main.go"},
+		{"/home/user/myapp/main.go", "myapp/main.go"},
+		{"/home/user/myapp/internal/server/http/handler.go", "http/handler.go"},
+	} {
+		if got := packageFile(tt.path); got != tt.want {
+			t.Errorf("packageFile(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestSrcBaseDir(t *testing.T) {
+	for _, tt := range []struct {
+		baseDir, path, want string
+	}{
+		{"/home/user/myapp", "/home/user/myapp/cmd/server/main.go", "cmd/server/main.go"},
+		{"/home/user/myapp/", "/home/user/myapp/cmd/server/main.go", "cmd/server/main.go"},
+		{"/home/user/myapp//cmd/..", "/home/user/myapp/main.go", "main.go"},
+		{"/home/user/myapp", "/home/user/myapplication/main.go", "main.go"},
+		{"/home/user/myapp", "/usr/local/go/src/runtime/proc.go", "proc.go"},
+	} {
+		h := NewHandler(io.Discard, &Options{SrcBaseDir: tt.baseDir})
+		if got := h.getRelativePath(tt.path); got != tt.want {
+			t.Errorf("SrcBaseDir %q: getRelativePath(%q) = %q, want %q", tt.baseDir, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestSrcMinLevel(t *testing.T) {
+	calls := 0
+	defer func(f func([]uintptr) *runtime.Frames) { callersFrames = f }(callersFrames)
+	callersFrames = func(pcs []uintptr) *runtime.Frames {
+		calls++
+		return runtime.CallersFrames(pcs)
+	}
+
+	var buf bytes.Buffer
+	h := NewHandler(&buf, &Options{Level: slog.LevelDebug, NoColor: true, NoTime: true, SrcFileMode: ShortFile, SrcMinLevel: slog.LevelWarn})
+	l := slog.New(h)
+	l.Info("info")
+	if calls != 0 {
+		t.Errorf("source resolved %d times below SrcMinLevel", calls)
+	}
+	l.Error("error")
+	if calls != 1 {
+		t.Errorf("source resolved %d times at SrcMinLevel, want 1", calls)
+	}
+
+	lines := strings.Split(buf.String(), "\n")
+	if lines[0] != "INFO  info" {
+		t.Errorf("got %q, want no source for info", lines[0])
+	}
+	if !strings.HasPrefix(lines[1], "ERROR sourceFileMode_test.go:") {
+		t.Errorf("got %q, want the source for error", lines[1])
+	}
+}
+
+func TestFormatSource(t *testing.T) {
+	frame := runtime.Frame{
+		Function: "github.com/foo/bar.(*Server).ServeHTTP",
+		File:     "/home/user/myapp/internal/server/main.go",
+		Line:     42,
+	}
+	h := NewHandler(io.Discard, &Options{SrcBaseDir: "/home/user/myapp"})
+	for _, tt := range []struct {
+		mode SourceFileMode
+		want string
+	}{
+		{Nop, ""},
+		{ShortFile, "main.go:42"},
+		{PackageFile, "server/main.go:42"},
+		{MediumFile, "internal/server/main.go:42"},
+		{LongFile, "/home/user/myapp/internal/server/main.go:42"},
+		{FuncName, "github.com/foo/bar.(*Server).ServeHTTP"},
+		{FuncShortName, "(*Server).ServeHTTP"},
+		{LineOnly, ":42"},
+	} {
+		if got := h.formatSource(frame, tt.mode); got != tt.want {
+			t.Errorf("formatSource(%d) = %q, want %q", tt.mode, got, tt.want)
+		}
+	}
+
+	// with SrcFileLength, the source is truncated and padded
+	for _, tt := range []struct {
+		mode   SourceFileMode
+		length int
+		want   string
+	}{
+		{ShortFile, 8, "main:42 "},
+		{ShortFile, 14, "main.go:42    "},
+		{LineOnly, 1, " "},
+		{LineOnly, 2, ": "},
+		{LineOnly, 10, ":42       "},
+	} {
+		h := NewHandler(io.Discard, &Options{NoColor: true, SrcFileMode: tt.mode, SrcFileLength: tt.length})
+		var bf bytes.Buffer
+		h.writeSource(&bf, &slog.Source{Function: frame.Function, File: frame.File, Line: frame.Line})
+		if got := bf.String(); got != tt.want {
+			t.Errorf("mode %d with SrcFileLength %d: got %q, want %q", tt.mode, tt.length, got, tt.want)
+		}
+	}
+}
+
+func TestSourceColor(t *testing.T) {
+	red, blue := color.New(color.FgRed), color.New(color.FgBlue)
+	for _, tt := range []struct {
+		name string
+		opts Options
+		want string
+	}{
+		{"line only", Options{NoColor: true}, `^INFO  :\d+ msg\n$`},
+		{"whole field", Options{ForceColor: true, SourceColor: red},
+			`^INFO  \x1b\[31mTestSourceColor \x1b\[0m\x1b\[31m:\d+ \x1b\[0mmsg\n$`},
+		{"file color first", Options{ForceColor: true, SourceColor: red, SrcFileColor: blue},
+			`^INFO  \x1b\[31mTestSourceColor \x1b\[0m\x1b\[34m:\d+ \x1b\[0mmsg\n$`},
+	} {
+		var buf bytes.Buffer
+		opts := tt.opts
+		opts.Level = slog.LevelInfo
+		opts.NoTime = true
+		opts.Theme = &Theme{}
+		opts.SrcFileMode = LineOnly
+		if opts.ForceColor {
+			opts.SrcFuncMode = FuncShortName
+		}
+		slog.New(NewHandler(&buf, &opts)).Info("msg")
+		if got := buf.String(); !regexp.MustCompile(tt.want).MatchString(got) {
+			t.Errorf("%s: got %q, want match of %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,12 @@
+package slogcolor
+
+// SrcPosition is the position of the source in the log line, see [Options.SrcPosition].
+type SrcPosition int
+
+const (
+	// SrcPositionAfterLevel prints the source between the level and the message.
+	SrcPositionAfterLevel SrcPosition = iota
+
+	// SrcPositionAfterAttrs prints the source at the end of the line, after the attributes.
+	SrcPositionAfterAttrs
+)
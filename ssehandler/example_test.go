@@ -0,0 +1,35 @@
+package ssehandler_test
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/geomyidia/slogcolor"
+	"github.com/geomyidia/slogcolor/ssehandler"
+)
+
+func Example() {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/logs", func(w http.ResponseWriter, r *http.Request) {
+		h, err := ssehandler.NewSSEHandler(w, &slogcolor.Options{Level: slog.LevelInfo, NoColor: true, NoTime: true})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		logger := slog.New(h)
+
+		// log with the context of the request, so that records are dropped once the client disconnected
+		logger.InfoContext(r.Context(), "connected", "remote", "browser")
+		logger.WarnContext(r.Context(), "disk almost full", "free", "5%")
+	})
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/logs", nil))
+	fmt.Print(rec.Body.String())
+	// Output:
+	// data: INFO  connected remote=browser
+	//
+	// data: WARN  disk almost full free=5%
+}
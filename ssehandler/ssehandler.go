@@ -0,0 +1,110 @@
+// Package ssehandler streams log records to a browser as Server-Sent Events, for tailing logs in a web page.
+package ssehandler
+
+import (
+	"context"
+	"errors"
+	"html"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync/atomic"
+
+	"github.com/geomyidia/slogcolor"
+)
+
+// ErrNoFlusher is returned by [NewSSEHandler] if the response writer cannot flush the events to the client.
+var ErrNoFlusher = errors.New("ssehandler: response writer does not implement http.Flusher")
+
+// handler passes the records to a [slogcolor.Handler] writing to the events writer.
+type handler struct {
+	next slog.Handler
+	w    *eventWriter
+}
+
+// NewSSEHandler returns a handler which sends every record to the client of w as a Server-Sent Event
+// and flushes it immediately. It sets the Content-Type header to text/event-stream.
+// The records are formatted by a [slogcolor.Handler] with opts, or [slogcolor.DefaultOptions] if opts is nil.
+// Unless opts.NoColor is set, the record keeps its ANSI escape sequences and is HTML-escaped,
+// for a client which converts the sequences to HTML; otherwise the event data is the plain text.
+// Each line of a record, for example of a stack trace, is a data line of the event.
+// BufferSize is ignored, as every record is sent at once.
+//
+// Records are dropped once the client disconnected: when the context passed to Handle is canceled,
+// for example the context of the request, or when writing to the client failed.
+// It returns [ErrNoFlusher] if w does not implement [http.Flusher].
+func NewSSEHandler(w http.ResponseWriter, opts *slogcolor.Options) (slog.Handler, error) {
+	f, ok := w.(http.Flusher)
+	if !ok {
+		return nil, ErrNoFlusher
+	}
+	if opts == nil {
+		opts = slogcolor.DefaultOptions
+	}
+	opts = opts.Clone()
+	opts.BufferSize = 0
+	opts.ForceColor = !opts.NoColor
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	ew := &eventWriter{w: w, f: f, escape: !opts.NoColor}
+	return &handler{next: slogcolor.NewHandler(ew, opts), w: ew}, nil
+}
+
+// Enabled implements slog.Handler.Enabled .
+func (h *handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return !h.w.gone.Load() && h.next.Enabled(ctx, level)
+}
+
+// Handle implements slog.Handler.Handle . It drops the record without an error if the client disconnected.
+func (h *handler) Handle(ctx context.Context, r slog.Record) error {
+	if ctx != nil && ctx.Err() != nil || h.w.gone.Load() {
+		return nil
+	}
+	return h.next.Handle(ctx, r)
+}
+
+// WithAttrs implements slog.Handler.WithAttrs .
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &handler{next: h.next.WithAttrs(attrs), w: h.w}
+}
+
+// WithGroup implements slog.Handler.WithGroup .
+func (h *handler) WithGroup(name string) slog.Handler {
+	return &handler{next: h.next.WithGroup(name), w: h.w}
+}
+
+// eventWriter writes every record, which the handler writes at once, as an event to w.
+// It is only used under the mutex of its handler.
+type eventWriter struct {
+	w      http.ResponseWriter
+	f      http.Flusher
+	escape bool
+	gone   atomic.Bool // writing to the client failed
+	buf    []byte
+}
+
+// Write implements io.Writer.
+func (e *eventWriter) Write(p []byte) (int, error) {
+	if e.gone.Load() {
+		return len(p), nil
+	}
+	e.buf = e.buf[:0]
+	for line := range strings.Lines(strings.TrimRight(string(p), "\r\n")) {
+		line = strings.TrimRight(line, "\r\n")
+		if e.escape {
+			line = html.EscapeString(line)
+		}
+		e.buf = append(e.buf, "data: "...)
+		e.buf = append(e.buf, line...)
+		e.buf = append(e.buf, '\n')
+	}
+	e.buf = append(e.buf, '\n')
+
+	if _, err := e.w.Write(e.buf); err != nil {
+		e.gone.Store(true)
+		return len(p), nil
+	}
+	e.f.Flush()
+	return len(p), nil
+}
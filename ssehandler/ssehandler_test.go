@@ -0,0 +1,114 @@
+package ssehandler_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"runtime"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/geomyidia/slogcolor"
+	"github.com/geomyidia/slogcolor/ssehandler"
+)
+
+func TestSSEHandler(t *testing.T) {
+	rec := httptest.NewRecorder()
+	h, err := ssehandler.NewSSEHandler(rec, &slogcolor.Options{Level: slog.LevelInfo, NoColor: true, NoTime: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := slog.New(h).With("app", "srv")
+	l.Info("hello", "html", "<b>")
+	l.Info("two lines", "text", "a\nb")
+
+	want := "data: INFO  hello app=srv html=<b>\n\n" +
+		"data: INFO  two lines app=srv text=a\ndata: b\n\n"
+	if got := rec.Body.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got := rec.Header().Get("Content-Type"); got != "text/event-stream" {
+		t.Errorf("got Content-Type %q", got)
+	}
+	if !rec.Flushed {
+		t.Error("records were not flushed")
+	}
+}
+
+func TestSSEHandlerColor(t *testing.T) {
+	t.Setenv("NO_COLOR", "") // ForceColor does not override NO_COLOR
+	rec := httptest.NewRecorder()
+	h, err := ssehandler.NewSSEHandler(rec, &slogcolor.Options{
+		Level:         slog.LevelInfo,
+		NoTime:        true,
+		Theme:         &slogcolor.Theme{},
+		KeyColor:      color.New(color.FgCyan),
+		StackTraceKey: "stack",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pcs := make([]uintptr, 1)
+	runtime.Callers(1, pcs)
+	slog.New(h).Info("hello", "html", "<b>", "stack", pcs)
+
+	want := regexp.MustCompile(`^data: INFO  hello \x1b\[36mhtml=\x1b\[0m&lt;b&gt;\n` +
+		`data:   \x1b\[36mstack:\x1b\[0m\n` +
+		`data:     github\.com/geomyidia/slogcolor/ssehandler_test\.TestSSEHandlerColor .*/ssehandler_test\.go:\d+\n\n$`)
+	if got := rec.Body.String(); !want.MatchString(got) {
+		t.Errorf("got %q, want match of %q", got, want)
+	}
+}
+
+// noFlusher is a response writer which cannot flush.
+type noFlusher struct{ http.ResponseWriter }
+
+func TestSSEHandlerNoFlusher(t *testing.T) {
+	if _, err := ssehandler.NewSSEHandler(noFlusher{httptest.NewRecorder()}, nil); !errors.Is(err, ssehandler.ErrNoFlusher) {
+		t.Errorf("got error %v, want ErrNoFlusher", err)
+	}
+}
+
+// brokenWriter fails like the connection of a disconnected client.
+type brokenWriter struct {
+	*httptest.ResponseRecorder
+	writes int
+}
+
+func (w *brokenWriter) Write(p []byte) (int, error) {
+	w.writes++
+	return 0, errors.New("broken pipe")
+}
+
+func TestSSEHandlerDisconnect(t *testing.T) {
+	rec := httptest.NewRecorder()
+	h, err := ssehandler.NewSSEHandler(rec, &slogcolor.Options{Level: slog.LevelInfo, NoColor: true, NoTime: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	slog.New(h).InfoContext(ctx, "dropped")
+	if got := rec.Body.String(); got != "" {
+		t.Errorf("got %q after the context was canceled", got)
+	}
+
+	w := &brokenWriter{ResponseRecorder: httptest.NewRecorder()}
+	h, err = ssehandler.NewSSEHandler(w, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := slog.New(h)
+	for range 3 {
+		l.Info("hello")
+	}
+	if w.writes != 1 {
+		t.Errorf("got %d writes, want 1 before the client is known to be gone", w.writes)
+	}
+	if h.Enabled(t.Context(), slog.LevelError) {
+		t.Error("handler is still enabled after the client disconnected")
+	}
+}
@@ -0,0 +1,93 @@
+package slogcolor
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime"
+)
+
+// StackTracer is implemented by attribute values that carry a stack trace, see [Options.StackTraceKey].
+type StackTracer interface {
+	StackTrace() []uintptr
+}
+
+// stackAttr is a stack trace printed beneath the log line.
+type stackAttr struct {
+	key string
+	pcs []uintptr
+}
+
+// stackTrace returns the stack trace carried by a, or nil if there is none or stack traces are not enabled.
+func (h *Handler) stackTrace(a slog.Attr) []uintptr {
+	if h.opts.StackTraceExtractor != nil {
+		if pcs := h.opts.StackTraceExtractor(a); pcs != nil {
+			return pcs
+		}
+	}
+	if h.opts.StackTraceKey == "" || a.Key != h.opts.StackTraceKey {
+		return nil
+	}
+	switch v := a.Value.Any().(type) {
+	case []uintptr:
+		return v
+	case StackTracer:
+		return v.StackTrace()
+	case error:
+		var st StackTracer
+		if errors.As(v, &st) {
+			return st.StackTrace()
+		}
+	}
+	return nil
+}
+
+// splitStacks removes the attributes which are stack traces from attrs and returns them separately.
+// Errors carrying a stack trace are kept, as their message is still printed inline.
+func (h *Handler) splitStacks(attrs []boundAttr) ([]boundAttr, []stackAttr) {
+	if h.opts.StackTraceKey == "" && h.opts.StackTraceExtractor == nil {
+		return attrs, nil
+	}
+	var stacks []stackAttr
+	rest := attrs[:0]
+	for _, ba := range attrs {
+		pcs := h.stackTrace(ba.attr)
+		if pcs == nil {
+			rest = append(rest, ba)
+			continue
+		}
+		stacks = append(stacks, stackAttr{key: ba.key(), pcs: pcs})
+		if _, isErr := ba.attr.Value.Any().(error); isErr {
+			rest = append(rest, ba)
+		}
+	}
+	return rest, stacks
+}
+
+// writeStacks writes the stack traces to bf, one frame per line, indented beneath the log line.
+func (h *Handler) writeStacks(bf *bytes.Buffer, stacks []stackAttr) {
+	for _, s := range stacks {
+		bf.WriteString("\n" + indent(1))
+		h.writeColor(bf, h.opts.KeyColor, s.key, ":")
+		h.writeFrames(bf, s.pcs, 0)
+	}
+}
+
+// writeFrames writes up to limit frames of the stack trace pcs to bf, or all frames if limit is 0.
+func (h *Handler) writeFrames(bf *bytes.Buffer, pcs []uintptr, limit int) {
+	if len(pcs) == 0 {
+		return
+	}
+	frames := runtime.CallersFrames(pcs)
+	for i := 1; ; i++ {
+		f, more := frames.Next()
+		bf.WriteString("\n" + indent(2))
+		h.writeColor(bf, h.opts.SrcFuncColor, f.Function)
+		bf.WriteString(" ")
+		h.writeColor(bf, h.opts.SrcFileColor, fmt.Sprintf("%s:%d", f.File, f.Line))
+		if !more || i == limit {
+			break
+		}
+	}
+}
@@ -2,16 +2,53 @@ package slogcolor
 
 import (
 	"bytes"
-	"regexp"
 )
 
-// re is the regular expression used for removing ANSI colors.
-var re = regexp.MustCompile("[\u001B\u009B][[\\]()#;?]*(?:(?:(?:[a-zA-Z\\d]*(?:;[a-zA-Z\\d]*)*)?\u0007)|(?:(?:\\d{1,4}(?:;\\d{0,4})*)?[\\dA-PRZcf-ntqry=><~]))")
+// escapeLen returns the length of the ANSI escape sequence at the start of b, or 0 if b does not start with one.
+// Both CSI sequences like colors and OSC sequences like hyperlinks are recognized.
+func escapeLen(b []byte) int {
+	if len(b) < 2 || b[0] != '\x1b' {
+		return 0
+	}
+	switch b[1] {
+	case '[':
+		for i := 2; i < len(b); i++ {
+			if b[i] >= 0x40 && b[i] <= 0x7e {
+				return i + 1
+			}
+		}
+	case ']':
+		for i := 2; i < len(b); i++ {
+			if b[i] == '\a' {
+				return i + 1
+			}
+			if b[i] == '\x1b' && i+1 < len(b) && b[i+1] == '\\' {
+				return i + 2
+			}
+		}
+	default:
+		return 2
+	}
+	return len(b)
+}
 
-// stripANSI removes ANSI escape sequences from the provided bytes.Buffer.
+// stripANSI removes ANSI escape sequences from the provided bytes.Buffer, in place.
 func stripANSI(bf *bytes.Buffer) {
+	stripANSIFrom(bf, 0)
+}
+
+// stripANSIFrom removes ANSI escape sequences from bf[start:], in place.
+func stripANSIFrom(bf *bytes.Buffer, start int) {
 	b := bf.Bytes()
-	cleaned := re.ReplaceAll(b, nil)
-	bf.Reset()
-	bf.Write(cleaned)
+	n := start
+	for i := start; i < len(b); {
+		if l := escapeLen(b[i:]); l > 0 {
+			i += l
+			continue
+		}
+		b[n] = b[i]
+		n++
+		i++
+	}
+	bf.Truncate(n)
 }
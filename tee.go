@@ -0,0 +1,116 @@
+package slogcolor
+
+import (
+	"errors"
+	"io"
+	"slices"
+)
+
+// TeeOutput is an output of [NewTeeHandler]. If Color is false, the escape sequences are removed
+// from the output written to Writer.
+type TeeOutput struct {
+	Writer io.Writer
+	Color  bool
+}
+
+// NewTeeHandler creates a new [Handler] that formats every record once and writes it to all outputs,
+// colored to those with Color set and plain to the others, for example colored output on the terminal
+// and a plain copy in a file. Unlike [NewMultiHandler], the source is resolved and the record formatted only once.
+// All outputs are written plain if color is disabled by opts or the NO_COLOR environment variable.
+// If opts is nil, uses [DefaultOptions].
+func NewTeeHandler(outputs []TeeOutput, opts *Options) *Handler {
+	if opts == nil {
+		opts = DefaultOptions
+	}
+	teeOpts := *opts
+	outputs = slices.Clone(outputs)
+	for i, o := range outputs {
+		if o.Color && !teeOpts.SkipWindowsInit && !enableWindowsConsole(o.Writer) {
+			outputs[i].Color = false // a legacy Windows console would print the escape sequences
+		}
+		if outputs[i].Color {
+			teeOpts.ForceColor = true
+		}
+	}
+	return NewHandler(&teeWriter{outputs: outputs}, &teeOpts)
+}
+
+// teeWriter writes to several outputs, removing the escape sequences for those without color.
+// It is only used under the mutex of its handler.
+type teeWriter struct {
+	outputs []TeeOutput
+	plain   []byte // the last write without escape sequences
+	pending []byte // an escape sequence cut off at the end of the last write, see stripEscapes
+}
+
+// Write implements io.Writer.
+func (t *teeWriter) Write(p []byte) (int, error) {
+	stripped := false
+	var err error
+	for _, o := range t.outputs {
+		b := p
+		if !o.Color {
+			if !stripped {
+				t.stripEscapes(p)
+				stripped = true
+			}
+			b = t.plain
+		}
+		if n, e := o.Writer.Write(b); e != nil {
+			err = errors.Join(err, e)
+		} else if n < len(b) {
+			err = errors.Join(err, io.ErrShortWrite)
+		}
+	}
+	return len(p), err
+}
+
+// Flush flushes or syncs all outputs, for [Options.FlushEachRecord].
+func (t *teeWriter) Flush() error {
+	var errs []error
+	for _, o := range t.outputs {
+		errs = append(errs, flushOutput(o.Writer))
+	}
+	return errors.Join(errs...)
+}
+
+// stripEscapes sets t.plain to p without escape sequences. An escape sequence which is not terminated
+// at the end of p, because a buffered writer split the record, is kept in t.pending for the next write.
+func (t *teeWriter) stripEscapes(p []byte) {
+	if len(t.pending) > 0 {
+		p = append(t.pending, p...)
+		t.pending = nil
+	}
+	t.plain = t.plain[:0]
+	for i := 0; i < len(p); {
+		l := escapeLen(p[i:])
+		if l == 0 && p[i] == '\x1b' && i == len(p)-1 {
+			l = 1 // the start of a sequence
+		}
+		if l == 0 {
+			t.plain = append(t.plain, p[i])
+			i++
+			continue
+		}
+		if i+l == len(p) && !escapeTerminated(p[i:]) {
+			t.pending = append([]byte(nil), p[i:]...)
+			return
+		}
+		i += l
+	}
+}
+
+// escapeTerminated reports whether seq, the escape sequence found by escapeLen at the end of a write, is complete.
+func escapeTerminated(seq []byte) bool {
+	if len(seq) < 2 {
+		return false
+	}
+	last := seq[len(seq)-1]
+	switch seq[1] {
+	case '[':
+		return len(seq) > 2 && last >= 0x40 && last <= 0x7e
+	case ']':
+		return last == '\a' || len(seq) > 3 && last == '\\' && seq[len(seq)-2] == '\x1b'
+	}
+	return true
+}
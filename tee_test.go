@@ -0,0 +1,39 @@
+package slogcolor_test
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/geomyidia/slogcolor"
+)
+
+func TestNewTeeHandler(t *testing.T) {
+	for _, bufferSize := range []int{0, 7} {
+		var colored, plain bytes.Buffer
+		h := slogcolor.NewTeeHandler([]slogcolor.TeeOutput{
+			{Writer: &colored, Color: true},
+			{Writer: &plain},
+		}, &slogcolor.Options{Level: slog.LevelInfo, NoTime: true, SrcFileMode: slogcolor.ShortFile, SrcHyperlink: true,
+			MsgPrefix: "| ", BufferSize: bufferSize})
+		l := slog.New(h).With("app", "srv")
+		l.Info("hello", "k", "v")
+		l.Error("failed", "err", "boom")
+		if err := h.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		if !strings.Contains(colored.String(), "\x1b[") {
+			t.Errorf("BufferSize %d: colored output has no escape sequences: %q", bufferSize, colored.String())
+		}
+		if strings.Contains(plain.String(), "\x1b") {
+			t.Errorf("BufferSize %d: plain output has escape sequences: %q", bufferSize, plain.String())
+		}
+		lines := strings.Split(plain.String(), "\n")
+		if len(lines) != 3 || !strings.HasPrefix(lines[0], "INFO  tee_test.go:") || !strings.HasSuffix(lines[0], " | hello app=srv k=v") ||
+			!strings.HasSuffix(lines[1], " | failed app=srv err=boom") {
+			t.Errorf("BufferSize %d: unexpected plain output %q", bufferSize, plain.String())
+		}
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !(aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris || zos || windows)
+
+package slogcolor
+
+import "io"
+
+// terminalWidth returns 0 on platforms where the width of the terminal cannot be queried, disabling wrapping.
+func terminalWidth(io.Writer) int {
+	return 0
+}
@@ -0,0 +1,190 @@
+package slogcolor
+
+import (
+	"log/slog"
+
+	"github.com/fatih/color"
+)
+
+// Theme is a set of colors used by the [Handler]. A nil color means no color.
+type Theme struct {
+	// Levels are the colors of the level tags.
+	Levels map[slog.Level]*color.Color
+
+	// Prefix is the color of the prefix of [Handler.WithPrefix].
+	Prefix *color.Color
+
+	// Time is the color of the timestamp.
+	Time *color.Color
+
+	// Source is the color of the source file info.
+	Source *color.Color
+
+	// Func is the color of the calling function, see [Options.SrcFuncMode].
+	Func *color.Color
+
+	// Message is the color of the message.
+	Message *color.Color
+
+	// Key is the color of attribute keys.
+	Key *color.Color
+
+	// Group is the color of group names, see [Options.GroupStyle].
+	Group *color.Color
+
+	// ErrorKey is the color of attribute keys containing "err".
+	ErrorKey *color.Color
+
+	// Value is the color of attribute values without a more specific color.
+	Value *color.Color
+
+	// String is the color of string values.
+	String *color.Color
+
+	// Number is the color of int, uint and float values.
+	Number *color.Color
+
+	// Bool is the color of bool values.
+	Bool *color.Color
+
+	// Null is the color of nil values.
+	Null *color.Color
+
+	// TimeValue is the color of time values.
+	TimeValue *color.Color
+
+	// Error is the color of attribute values that are errors.
+	Error *color.Color
+}
+
+// ThemeDefault is the default 16-color theme.
+var ThemeDefault = &Theme{
+	Levels: map[slog.Level]*color.Color{
+		slog.LevelDebug: color.New(color.BgCyan, color.FgHiWhite),
+		slog.LevelInfo:  color.New(color.BgGreen, color.FgHiWhite),
+		slog.LevelWarn:  color.New(color.BgYellow, color.FgHiWhite),
+		slog.LevelError: color.New(color.BgRed, color.FgHiWhite),
+	},
+	Prefix:   color.New(color.BgHiWhite, color.FgBlack),
+	Time:     color.New(color.Faint),
+	Func:     color.New(color.FgMagenta),
+	Key:      color.New(color.FgCyan),
+	Group:    color.New(color.FgBlue, color.Bold),
+	ErrorKey: color.New(color.FgRed),
+	Error:    color.New(color.FgRed),
+}
+
+// ThemeDark is the theme for terminals with a dark background, which is [ThemeDefault].
+var ThemeDark = ThemeDefault
+
+// ThemeLight is a 16-color theme for terminals with a light background, avoiding the bright colors.
+var ThemeLight = &Theme{
+	Levels: map[slog.Level]*color.Color{
+		slog.LevelDebug: color.New(color.BgBlue, color.FgWhite),
+		slog.LevelInfo:  color.New(color.BgGreen, color.FgBlack),
+		slog.LevelWarn:  color.New(color.BgYellow, color.FgBlack),
+		slog.LevelError: color.New(color.BgRed, color.FgWhite),
+	},
+	Prefix:   color.New(color.BgBlack, color.FgWhite),
+	Time:     color.New(color.FgHiBlack),
+	Func:     color.New(color.FgMagenta),
+	Key:      color.New(color.FgBlue),
+	Group:    color.New(color.FgBlue, color.Bold),
+	ErrorKey: color.New(color.FgRed),
+	Error:    color.New(color.FgRed),
+}
+
+// ThemeMonochrome is a theme without colors, only with bold, underlined and dim text, for example
+// for colorblind users. The escape sequences are still written, unless colors are disabled.
+var ThemeMonochrome = &Theme{
+	Levels: map[slog.Level]*color.Color{
+		slog.LevelDebug: color.New(color.Faint),
+		slog.LevelInfo:  color.New(color.Bold),
+		slog.LevelWarn:  color.New(color.Underline),
+		slog.LevelError: color.New(color.Bold, color.Underline),
+	},
+	Prefix:   color.New(color.Bold),
+	Time:     color.New(color.Faint),
+	Func:     color.New(color.Faint),
+	Source:   color.New(color.Faint),
+	Key:      color.New(color.Faint),
+	Group:    color.New(color.Bold),
+	ErrorKey: color.New(color.Bold),
+	Error:    color.New(color.Bold),
+}
+
+// ThemeTrueColor is the default theme for terminals with 24-bit color support, see [Options.TrueColor].
+var ThemeTrueColor = &Theme{
+	Levels: map[slog.Level]*color.Color{
+		slog.LevelDebug: RGBColor{97, 175, 239}.Bg().AddRGB(40, 44, 52),
+		slog.LevelInfo:  RGBColor{152, 195, 121}.Bg().AddRGB(40, 44, 52),
+		slog.LevelWarn:  RGBColor{229, 192, 123}.Bg().AddRGB(40, 44, 52),
+		slog.LevelError: RGBColor{224, 108, 117}.Bg().AddRGB(40, 44, 52),
+	},
+	Prefix:   RGBColor{171, 178, 191}.Bg().AddRGB(40, 44, 52),
+	Time:     color.New(color.Faint),
+	Func:     RGBColor{198, 120, 221}.Fg(),
+	Key:      RGBColor{86, 182, 194}.Fg(),
+	Group:    RGBColor{97, 175, 239}.Fg().Add(color.Bold),
+	ErrorKey: RGBColor{224, 108, 117}.Fg(),
+	Error:    RGBColor{224, 108, 117}.Fg(),
+}
+
+// ThemeDracula is a 24-bit theme based on the Dracula color scheme (https://draculatheme.com).
+var ThemeDracula = &Theme{
+	Levels: map[slog.Level]*color.Color{
+		slog.LevelDebug: RGBColor{189, 147, 249}.Bg().AddRGB(40, 42, 54),
+		slog.LevelInfo:  RGBColor{80, 250, 123}.Bg().AddRGB(40, 42, 54),
+		slog.LevelWarn:  RGBColor{255, 184, 108}.Bg().AddRGB(40, 42, 54),
+		slog.LevelError: RGBColor{255, 85, 85}.Bg().AddRGB(40, 42, 54),
+	},
+	Prefix:    RGBColor{68, 71, 90}.Bg().AddRGB(248, 248, 242),
+	Time:      RGBColor{98, 114, 164}.Fg(),
+	Source:    RGBColor{255, 121, 198}.Fg(),
+	Func:      RGBColor{80, 250, 123}.Fg(),
+	Message:   RGBColor{248, 248, 242}.Fg(),
+	Key:       RGBColor{139, 233, 253}.Fg(),
+	Group:     RGBColor{189, 147, 249}.Fg().Add(color.Bold),
+	ErrorKey:  RGBColor{255, 85, 85}.Fg(),
+	Value:     RGBColor{248, 248, 242}.Fg(),
+	String:    RGBColor{241, 250, 140}.Fg(),
+	Number:    RGBColor{189, 147, 249}.Fg(),
+	Bool:      RGBColor{189, 147, 249}.Fg(),
+	Null:      RGBColor{98, 114, 164}.Fg(),
+	TimeValue: RGBColor{255, 184, 108}.Fg(),
+	Error:     RGBColor{255, 85, 85}.Fg(),
+}
+
+// ThemeSolarizedDark is a 24-bit theme based on the dark Solarized color scheme (https://ethanschoonover.com/solarized).
+var ThemeSolarizedDark = &Theme{
+	Levels: map[slog.Level]*color.Color{
+		slog.LevelDebug: RGBColor{108, 113, 196}.Bg().AddRGB(0, 43, 54),
+		slog.LevelInfo:  RGBColor{133, 153, 0}.Bg().AddRGB(0, 43, 54),
+		slog.LevelWarn:  RGBColor{181, 137, 0}.Bg().AddRGB(0, 43, 54),
+		slog.LevelError: RGBColor{220, 50, 47}.Bg().AddRGB(0, 43, 54),
+	},
+	Prefix:    RGBColor{7, 54, 66}.Bg().AddRGB(147, 161, 161),
+	Time:      RGBColor{88, 110, 117}.Fg(),
+	Source:    RGBColor{38, 139, 210}.Fg(),
+	Func:      RGBColor{108, 113, 196}.Fg(),
+	Message:   RGBColor{147, 161, 161}.Fg(),
+	Key:       RGBColor{42, 161, 152}.Fg(),
+	Group:     RGBColor{211, 54, 130}.Fg().Add(color.Bold),
+	ErrorKey:  RGBColor{203, 75, 22}.Fg(),
+	Value:     RGBColor{131, 148, 150}.Fg(),
+	String:    RGBColor{133, 153, 0}.Fg(),
+	Number:    RGBColor{108, 113, 196}.Fg(),
+	Bool:      RGBColor{181, 137, 0}.Fg(),
+	Null:      RGBColor{88, 110, 117}.Fg(),
+	TimeValue: RGBColor{38, 139, 210}.Fg(),
+	Error:     RGBColor{220, 50, 47}.Fg(),
+}
+
+// levelTags returns the level tags for the level colors of t.
+func (t *Theme) levelTags() map[slog.Level]string {
+	tags := make(map[slog.Level]string, len(t.Levels))
+	for l, c := range t.Levels {
+		tags[l] = sprint(c, levelText(l))
+	}
+	return tags
+}
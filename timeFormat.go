@@ -0,0 +1,105 @@
+package slogcolor
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Time formats for [Options.TimeFormat]. Any other value is used as a layout for [time.Time.Format].
+const (
+	// TimeFormatRFC3339 formats the time as in 2006-01-02T15:04:05Z07:00.
+	TimeFormatRFC3339 = time.RFC3339
+
+	// TimeFormatKitchen formats the time as in 3:04PM.
+	TimeFormatKitchen = time.Kitchen
+
+	// TimeFormatUnix formats the time as Unix seconds.
+	TimeFormatUnix = "unix"
+
+	// TimeFormatUnixMilli formats the time as Unix milliseconds.
+	TimeFormatUnixMilli = "unixmilli"
+
+	// TimeFormatRelative formats the time as the duration since the handler was created (for example +1.234s).
+	TimeFormatRelative = "relative"
+
+	// TimeFormatRelativeHuman formats the age of the record when it is written, for example "just now", "35s ago",
+	// "4m ago" or "2d ago".
+	TimeFormatRelativeHuman = "relativehuman"
+)
+
+// defaultTimeFormat is used if [Options.TimeFormat] is empty.
+const defaultTimeFormat = time.DateTime
+
+// withPrecision returns layout with the fractional seconds after the seconds element replaced according to p.
+// Layouts without seconds are returned unchanged.
+func withPrecision(layout string, p TimePrecision) string {
+	if p == TimePrecisionLayout {
+		return layout
+	}
+	i := strings.Index(layout, "05")
+	if i < 0 {
+		return layout
+	}
+	i += len("05")
+	rest := layout[i:]
+	if len(rest) > 1 && (rest[0] == '.' || rest[0] == ',') && (rest[1] == '0' || rest[1] == '9') {
+		rest = strings.TrimLeft(rest[1:], rest[1:2])
+	}
+	if n := p.digits(); n > 0 {
+		return layout[:i] + "." + strings.Repeat("0", n) + rest
+	}
+	return layout[:i] + rest
+}
+
+// formatTime formats the value of the time attribute with [Options.TimeFormat].
+func (h *Handler) formatTime(v slog.Value) string {
+	return string(h.appendTime(nil, v))
+}
+
+// appendTime appends the value of the time attribute, formatted with [Options.TimeFormat], to b.
+func (h *Handler) appendTime(b []byte, v slog.Value) []byte {
+	if v.Kind() != slog.KindTime {
+		return append(b, v.String()...)
+	}
+
+	t := v.Time()
+	switch h.opts.TimeFormat {
+	case TimeFormatUnix:
+		return strconv.AppendInt(b, t.Unix(), 10)
+	case TimeFormatUnixMilli:
+		return strconv.AppendInt(b, t.UnixMilli(), 10)
+	case TimeFormatRelative:
+		digits := 3
+		if h.opts.TimePrecision != TimePrecisionLayout {
+			digits = h.opts.TimePrecision.digits()
+		}
+		return fmt.Appendf(b, "%+.*fs", digits, t.Sub(*h.start.Load()).Seconds())
+	case TimeFormatRelativeHuman:
+		return appendAge(b, time.Since(t))
+	}
+	return t.AppendFormat(b, h.opts.TimeFormat)
+}
+
+// appendAge appends the age d for [TimeFormatRelativeHuman], in the largest unit of seconds, minutes, hours and days, to b.
+func appendAge(b []byte, d time.Duration) []byte {
+	switch {
+	case d < time.Second:
+		return append(b, "just now"...)
+	case d < time.Minute:
+		return fmt.Appendf(b, "%ds ago", d/time.Second)
+	case d < time.Hour:
+		return fmt.Appendf(b, "%dm ago", d/time.Minute)
+	case d < 24*time.Hour:
+		return fmt.Appendf(b, "%dh ago", d/time.Hour)
+	}
+	return fmt.Appendf(b, "%dd ago", d/(24*time.Hour))
+}
+
+// SetStartTime sets the time that [TimeFormatRelative] is relative to, for all clones of the handler.
+// It is the creation time of the handler by default, and can be reset with h.SetStartTime(time.Now()).
+func (h *Handler) SetStartTime(t time.Time) {
+	h.start.Store(&t)
+}
@@ -0,0 +1,166 @@
+package slogcolor_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/geomyidia/slogcolor"
+)
+
+func TestTimeFormat(t *testing.T) {
+	tm := time.Date(2024, 1, 2, 15, 4, 5, 678_000_000, time.UTC)
+	for _, tt := range []struct {
+		format string
+		want   string
+	}{
+		{"", `^2024-01-02 15:04:05 `},
+		{slogcolor.TimeFormatRFC3339, `^2024-01-02T15:04:05Z `},
+		{slogcolor.TimeFormatKitchen, `^3:04PM `},
+		{slogcolor.TimeFormatUnix, `^1704207845 `},
+		{slogcolor.TimeFormatUnixMilli, `^\d+ `},
+		{slogcolor.TimeFormatRelative, `^\+0\.\d{3}s `},
+	} {
+		var buf bytes.Buffer
+		h := slogcolor.NewHandler(&buf, &slogcolor.Options{
+			Level:      slog.LevelInfo,
+			NoColor:    true,
+			TimeFormat: tt.format,
+			OmitFields: slogcolor.OmitLevel | slogcolor.OmitMessage,
+		})
+		r := slog.NewRecord(tm, slog.LevelInfo, "msg", 0)
+		if tt.format == slogcolor.TimeFormatRelative {
+			r.Time = time.Now()
+		}
+		h.Handle(context.Background(), r)
+		if got := buf.String(); !regexp.MustCompile(tt.want + "\n$").MatchString(got) {
+			t.Errorf("TimeFormat %q: got %q, want %s", tt.format, got, tt.want)
+		}
+	}
+}
+
+func TestTimeLocation(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip(err)
+	}
+	tm := time.Date(2024, 1, 2, 15, 4, 5, 0, time.FixedZone("CET", 3600))
+	for _, tt := range []struct {
+		name string
+		opts slogcolor.Options
+		want string
+	}{
+		{"as provided", slogcolor.Options{}, "2024-01-02T15:04:05+01:00 \n"},
+		{"UTC", slogcolor.Options{UTC: true}, "2024-01-02T14:04:05Z \n"},
+		{"TimeLocation", slogcolor.Options{TimeLocation: newYork}, "2024-01-02T09:04:05-05:00 \n"},
+		{"TimeLocation over UTC", slogcolor.Options{TimeLocation: newYork, UTC: true}, "2024-01-02T09:04:05-05:00 \n"},
+	} {
+		var buf bytes.Buffer
+		opts := tt.opts
+		opts.Level = slog.LevelInfo
+		opts.NoColor = true
+		opts.TimeFormat = slogcolor.TimeFormatRFC3339
+		opts.OmitFields = slogcolor.OmitLevel | slogcolor.OmitMessage
+		slogcolor.NewHandler(&buf, &opts).Handle(context.Background(), slog.NewRecord(tm, slog.LevelInfo, "msg", 0))
+		if got := buf.String(); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestTimePrecision(t *testing.T) {
+	tm := time.Date(2024, 1, 2, 15, 4, 5, 678_901_234, time.UTC)
+	for _, tt := range []struct {
+		format    string
+		precision slogcolor.TimePrecision
+		want      string
+	}{
+		{"", slogcolor.TimePrecisionLayout, "2024-01-02 15:04:05"},
+		{"", slogcolor.TimePrecisionSeconds, "2024-01-02 15:04:05"},
+		{"", slogcolor.TimePrecisionMillis, "2024-01-02 15:04:05.678"},
+		{"", slogcolor.TimePrecisionMicros, "2024-01-02 15:04:05.678901"},
+		{"", slogcolor.TimePrecisionNanos, "2024-01-02 15:04:05.678901234"},
+		{time.RFC3339Nano, slogcolor.TimePrecisionMillis, "2024-01-02T15:04:05.678Z"},
+		{time.RFC3339Nano, slogcolor.TimePrecisionSeconds, "2024-01-02T15:04:05Z"},
+		{"15:04:05.000 MST", slogcolor.TimePrecisionMicros, "15:04:05.678901 UTC"},
+		{slogcolor.TimeFormatKitchen, slogcolor.TimePrecisionMillis, "3:04PM"},
+		{slogcolor.TimeFormatUnix, slogcolor.TimePrecisionMillis, "1704207845"},
+	} {
+		var buf bytes.Buffer
+		h := slogcolor.NewHandler(&buf, &slogcolor.Options{
+			Level:         slog.LevelInfo,
+			NoColor:       true,
+			TimeFormat:    tt.format,
+			TimePrecision: tt.precision,
+			OmitFields:    slogcolor.OmitLevel | slogcolor.OmitMessage,
+		})
+		h.Handle(context.Background(), slog.NewRecord(tm, slog.LevelInfo, "msg", 0))
+		if got, want := buf.String(), tt.want+" \n"; got != want {
+			t.Errorf("TimeFormat %q with precision %d: got %q, want %q", tt.format, tt.precision, got, want)
+		}
+	}
+
+	var buf bytes.Buffer
+	h := slogcolor.NewHandler(&buf, &slogcolor.Options{
+		Level:         slog.LevelInfo,
+		NoColor:       true,
+		TimeFormat:    slogcolor.TimeFormatRelative,
+		TimePrecision: slogcolor.TimePrecisionMicros,
+		OmitFields:    slogcolor.OmitLevel | slogcolor.OmitMessage,
+	})
+	h.Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelInfo, "msg", 0))
+	if got := buf.String(); !regexp.MustCompile(`^\+0\.\d{6}s \n$`).MatchString(got) {
+		t.Errorf("relative: got %q", got)
+	}
+}
+
+func TestSetStartTime(t *testing.T) {
+	var buf bytes.Buffer
+	h := slogcolor.NewHandler(&buf, &slogcolor.Options{
+		Level:      slog.LevelInfo,
+		NoColor:    true,
+		TimeFormat: slogcolor.TimeFormatRelative,
+		OmitFields: slogcolor.OmitLevel,
+	})
+	l := h.WithAttrs([]slog.Attr{slog.Int("i", 1)})
+	start := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+	h.SetStartTime(start)
+
+	l.Handle(context.Background(), slog.NewRecord(start.Add(1234*time.Millisecond), slog.LevelInfo, "later", 0))
+	h.SetStartTime(start.Add(time.Minute))
+	l.Handle(context.Background(), slog.NewRecord(start.Add(time.Minute+5*time.Millisecond), slog.LevelInfo, "reset", 0))
+	h.Handle(context.Background(), slog.NewRecord(start, slog.LevelInfo, "before", 0))
+
+	want := "+1.234s later i=1\n+0.005s reset i=1\n-60.000s before\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestTimeFormatRelativeHuman(t *testing.T) {
+	for _, tt := range []struct {
+		age  time.Duration
+		want string
+	}{
+		{0, "just now"},
+		{35 * time.Second, "35s ago"},
+		{4*time.Minute + 10*time.Second, "4m ago"},
+		{3 * time.Hour, "3h ago"},
+		{50 * time.Hour, "2d ago"},
+	} {
+		var buf bytes.Buffer
+		h := slogcolor.NewHandler(&buf, &slogcolor.Options{
+			Level:      slog.LevelInfo,
+			NoColor:    true,
+			TimeFormat: slogcolor.TimeFormatRelativeHuman,
+			OmitFields: slogcolor.OmitLevel,
+		})
+		h.Handle(context.Background(), slog.NewRecord(time.Now().Add(-tt.age), slog.LevelInfo, "msg", 0))
+		if got, want := buf.String(), tt.want+" msg\n"; got != want {
+			t.Errorf("age %v: got %q, want %q", tt.age, got, want)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package slogcolor
+
+// TimePrecision is the precision of the fractional seconds of the timestamp, see [Options.TimePrecision].
+type TimePrecision int
+
+const (
+	// TimePrecisionLayout keeps the precision of [Options.TimeFormat].
+	TimePrecisionLayout TimePrecision = iota
+
+	// TimePrecisionSeconds prints whole seconds (for example 15:04:05).
+	TimePrecisionSeconds
+
+	// TimePrecisionMillis prints milliseconds (for example 15:04:05.678).
+	TimePrecisionMillis
+
+	// TimePrecisionMicros prints microseconds (for example 15:04:05.678901).
+	TimePrecisionMicros
+
+	// TimePrecisionNanos prints nanoseconds (for example 15:04:05.678901234).
+	TimePrecisionNanos
+)
+
+// digits returns the number of fractional digits of p.
+func (p TimePrecision) digits() int {
+	switch p {
+	case TimePrecisionMillis:
+		return 3
+	case TimePrecisionMicros:
+		return 6
+	case TimePrecisionNanos:
+		return 9
+	}
+	return 0
+}
@@ -0,0 +1,96 @@
+package slogcolor
+
+import (
+	"bytes"
+	"strings"
+	"unicode/utf8"
+)
+
+// defaultEllipsis is the default of [Options.EllipsisStyle].
+const defaultEllipsis = "…"
+
+// visibleLen returns the number of characters in b which are shown in a terminal, i.e. without escape sequences.
+func visibleLen(b []byte) int {
+	n := 0
+	for i := 0; i < len(b); {
+		if l := escapeLen(b[i:]); l > 0 {
+			i += l
+			continue
+		}
+		_, size := utf8.DecodeRune(b[i:])
+		i += size
+		n++
+	}
+	return n
+}
+
+// truncateVisible returns the prefix of b with n visible characters, keeping the escape sequences before the cut.
+func truncateVisible(b []byte, n int) []byte {
+	for i := 0; i < len(b); {
+		if l := escapeLen(b[i:]); l > 0 {
+			i += l
+			continue
+		}
+		if n == 0 {
+			return b[:i]
+		}
+		_, size := utf8.DecodeRune(b[i:])
+		i += size
+		n--
+	}
+	return b
+}
+
+// fitLine shortens the first line of bf, which ends at end, to [Options.MaxLineWidth] visible characters.
+// The source in bf[srcStart:srcEnd] is dropped first, then the line is truncated, which cuts the attributes
+// before the message, and [Options.EllipsisStyle] is appended.
+func (h *Handler) fitLine(bf *bytes.Buffer, srcStart, srcEnd, end int) {
+	b := bf.Bytes()
+	if visibleLen(b[:end]) <= h.opts.MaxLineWidth {
+		return
+	}
+
+	line := make([]byte, 0, len(b))
+	line = append(line, b[:srcStart]...)
+	line = append(line, b[srcEnd:end]...)
+	if visibleLen(line) > h.opts.MaxLineWidth {
+		line = truncateVisible(line, max(0, h.opts.MaxLineWidth-utf8.RuneCountInString(h.opts.EllipsisStyle)))
+		if !h.opts.NoColor {
+			line = append(line, "\x1b[0m"...)
+		}
+		line = append(line, h.opts.EllipsisStyle...)
+	}
+	line = append(line, b[end:]...)
+
+	bf.Reset()
+	bf.Write(line)
+}
+
+// truncateMessage truncates msg to [Options.MaxMessageLength] visible characters and appends [Options.EllipsisStyle]
+// if it is longer. Escape sequences in msg are kept whole, and reset before the ellipsis.
+func (h *Handler) truncateMessage(msg string) string {
+	b := []byte(msg)
+	if visibleLen(b) <= h.opts.MaxMessageLength {
+		return msg
+	}
+	s := string(truncateVisible(b, h.opts.MaxMessageLength))
+	if strings.IndexByte(msg, '\x1b') >= 0 {
+		s += "\x1b[0m"
+	}
+	return s + h.opts.EllipsisStyle
+}
+
+// truncateValue truncates the attribute value in bf[start:] to [Options.MaxAttrValueLen] visible characters
+// and appends [Options.EllipsisStyle] if it is longer.
+func (h *Handler) truncateValue(bf *bytes.Buffer, start int) {
+	v := bf.Bytes()[start:]
+	if visibleLen(v) <= h.opts.MaxAttrValueLen {
+		return
+	}
+	colored := bytes.IndexByte(v, '\x1b') >= 0
+	bf.Truncate(start + len(truncateVisible(v, h.opts.MaxAttrValueLen)))
+	if colored {
+		bf.WriteString("\x1b[0m")
+	}
+	bf.WriteString(h.opts.EllipsisStyle)
+}
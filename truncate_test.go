@@ -0,0 +1,38 @@
+package slogcolor
+
+import "testing"
+
+func TestVisibleLen(t *testing.T) {
+	for _, tt := range []struct {
+		s    string
+		want int
+	}{
+		{"", 0},
+		{"plain", 5},
+		{"\x1b[42;97mINFO \x1b[0m", 5},
+		{"\x1b]8;;file:///main.go#1\x1b\\main.go:1\x1b]8;;\x1b\\", 9},
+		{"größe…", 6},
+	} {
+		if got := visibleLen([]byte(tt.s)); got != tt.want {
+			t.Errorf("visibleLen(%q) = %d, want %d", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestTruncateVisible(t *testing.T) {
+	for _, tt := range []struct {
+		s    string
+		n    int
+		want string
+	}{
+		{"plain", 3, "pla"},
+		{"plain", 10, "plain"},
+		{"\x1b[31mred\x1b[0m blue", 3, "\x1b[31mred\x1b[0m"},
+		{"\x1b[31mred\x1b[0m blue", 2, "\x1b[31mre"},
+		{"größe", 3, "grö"},
+	} {
+		if got := string(truncateVisible([]byte(tt.s), tt.n)); got != tt.want {
+			t.Errorf("truncateVisible(%q, %d) = %q, want %q", tt.s, tt.n, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package slogcolor
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// displayWidth returns the number of terminal columns taken by s, counting wide characters like CJK and emoji
+// as two columns and combining marks and joiners as none. Emoji sequences joined with ZWJ are overestimated.
+func displayWidth(s string) int {
+	w, prev := 0, 0
+	for _, r := range s {
+		if r == 0xFE0F { // emoji presentation selector, renders the preceding symbol wide
+			if prev == 1 {
+				w++
+				prev = 2
+			}
+			continue
+		}
+		if rw := runeWidth(r); rw > 0 {
+			w += rw
+			prev = rw
+		}
+	}
+	return w
+}
+
+// runeWidth returns the number of terminal columns taken by r.
+func runeWidth(r rune) int {
+	switch {
+	case r == 0x200D || (r >= 0xFE00 && r <= 0xFE0F) || unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf):
+		return 0
+	case isWide(r):
+		return 2
+	}
+	return 1
+}
+
+// fitWidth pads s with spaces or truncates it to width columns. A truncated s ends with an ellipsis.
+func fitWidth(s string, width int) string {
+	w := displayWidth(s)
+	if w <= width {
+		return s + strings.Repeat(" ", width-w)
+	}
+	w = 0
+	for i, r := range s {
+		if w+runeWidth(r) > width-1 {
+			return s[:i] + "…" + strings.Repeat(" ", width-1-w)
+		}
+		w += runeWidth(r)
+	}
+	return s
+}
+
+// truncateWidth returns the longest prefix of s which takes at most width columns.
+func truncateWidth(s string, width int) string {
+	for i := range s {
+		_, size := utf8.DecodeRuneInString(s[i:])
+		if displayWidth(s[:i+size]) > width {
+			return s[:i]
+		}
+	}
+	return s
+}
+
+// wideRanges are the ranges of characters which take two terminal columns.
+var wideRanges = [][2]rune{
+	{0x1100, 0x115F},   // Hangul Jamo
+	{0x2E80, 0x303E},   // CJK radicals and punctuation
+	{0x3041, 0xA4CF},   // Kana, CJK ideographs and Yi
+	{0xAC00, 0xD7A3},   // Hangul syllables
+	{0xF900, 0xFAFF},   // CJK compatibility ideographs
+	{0xFE30, 0xFE4F},   // CJK compatibility forms
+	{0xFF00, 0xFF60},   // fullwidth forms
+	{0xFFE0, 0xFFE6},   // fullwidth signs
+	{0x1F300, 0x1F64F}, // pictographs and emoticons
+	{0x1F680, 0x1F6FF}, // transport and map symbols
+	{0x1F900, 0x1F9FF}, // supplemental symbols and pictographs
+	{0x1FA70, 0x1FAFF}, // symbols and pictographs extended-A
+	{0x20000, 0x3FFFD}, // CJK ideographs extensions
+}
+
+// isWide reports whether r takes two terminal columns.
+func isWide(r rune) bool {
+	for _, rg := range wideRanges {
+		if r >= rg[0] && r <= rg[1] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,23 @@
+package slogcolor
+
+import "testing"
+
+func TestDisplayWidth(t *testing.T) {
+	for _, tt := range []struct {
+		s    string
+		want int
+	}{
+		{"", 0},
+		{"INFO", 4},
+		{"größe", 5},
+		{"é", 1},
+		{"🔥", 2},
+		{"⚠", 1},
+		{"⚠️", 2},
+		{"日本", 4},
+	} {
+		if got := displayWidth(tt.s); got != tt.want {
+			t.Errorf("displayWidth(%q) = %d, want %d", tt.s, got, tt.want)
+		}
+	}
+}